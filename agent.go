@@ -2,22 +2,54 @@ package orenoagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"time"
 
+	"github.com/demouth/orenoagent-go/metrics"
 	"github.com/demouth/orenoagent-go/provider"
 	"github.com/demouth/orenoagent-go/util"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Agent struct {
-	prov provider.Provider
+	prov  provider.Provider
+	hooks []Hooks
+
+	tracer       trace.Tracer
+	traceContent bool
+
+	logger  *slog.Logger
+	metrics metrics.Metrics
+
+	// tools are the tools set with WithTools.
+	tools []provider.Tool
+
+	// planner is set in planning mode, reflector in reflection mode and
+	// retrieval with WithRetrieval.
+	planner   *planner
+	reflector *reflector
+	retrieval *retrieval
 }
 
 // AgentOption configures an Agent.
 type AgentOption func(*Agent)
 
+// WithLogger sets the logger. Runs are logged at debug level, and failures
+// at warn level. By default nothing is logged. Pass the same logger to the
+// provider to see its requests and tool calls.
+func WithLogger(logger *slog.Logger) AgentOption {
+	return func(a *Agent) {
+		a.logger = logger
+	}
+}
+
 // WithTools sets the tools available to the agent.
 func WithTools(tools []provider.Tool) AgentOption {
 	return func(a *Agent) {
+		a.tools = tools
 		a.prov.SetTools(tools)
 	}
 }
@@ -31,13 +63,30 @@ func WithTools(tools []provider.Tool) AgentOption {
 //	agent := orenoagent.NewAgent(provider, orenoagent.WithTools(tools))
 func NewAgent(prov provider.Provider, opts ...AgentOption) *Agent {
 	agent := &Agent{
-		prov: prov,
+		prov:   prov,
+		logger: slog.New(slog.DiscardHandler),
 	}
 
 	for _, opt := range opts {
 		opt(agent)
 	}
 
+	if agent.retrieval != nil && agent.retrieval.toolName != "" {
+		prov.SetTools(append(slices.Clone(agent.tools), agent.retrieval.tool()))
+	}
+	if agent.metrics != nil {
+		agent.hooks = append([]Hooks{agent.metricsHooks()}, agent.hooks...)
+	}
+	if agent.tracer != nil {
+		// Tracing runs first so that other hooks see the span in ctx.
+		agent.hooks = append([]Hooks{agent.tracingHooks()}, agent.hooks...)
+	}
+	if len(agent.hooks) > 0 {
+		if hp, ok := prov.(provider.HooksProvider); ok {
+			hp.SetHooks(agent.providerHooks())
+		}
+	}
+
 	return agent
 }
 
@@ -47,45 +96,121 @@ func (a *Agent) Ask(ctx context.Context, question string) (*util.Subscriber[Resu
 	go func() {
 		defer subscriber.Close()
 
+		// The run span comes first so that OnResult hooks see it in ctx for
+		// every result, including RunStartedResult.
+		ctx, endRun := a.startRun(ctx, question)
+		publish := func(result Result) bool {
+			if result = a.onResult(ctx, result); result == nil {
+				return true
+			}
+			return subscriber.Publish(result)
+		}
+
+		publish(NewRunStartedResult(question))
+
+		runMetrics := a.startRunMetrics()
+		start := time.Now()
+		a.logger.DebugContext(ctx, "run started", "provider", provider.NameOf(a.prov), "question_bytes", len(question))
+		finishReason := FinishReasonStop
+		var answer string
 		yield := func(providerResult provider.Result) bool {
-			agentResult, err := convertProviderResult(providerResult)
+			agentResult, err := convertProviderResult(providerResult, runMetrics.firstTokenFunc())
 			if err != nil {
-				subscriber.Publish(NewErrorResult(err))
+				a.logger.WarnContext(ctx, "cannot convert provider result", "error", err)
+				publish(NewErrorResult(err))
 				return false
 			}
-			return subscriber.Publish(agentResult)
+			runMetrics.observe(agentResult)
+			switch r := agentResult.(type) {
+			case *ModelCallCompletedResult:
+				finishReason = r.FinishReason()
+			case *MessageResult:
+				answer += r.String()
+			}
+			return publish(agentResult)
 		}
 
-		err := a.prov.ProcessMessage(ctx, yield, question)
-		if err != nil {
-			subscriber.Publish(NewErrorResult(err))
-			return
+		// Agents running as tools publish into this run through ctx.
+		ctx = context.WithValue(ctx, publisherKey{}, publish)
+		if a.retrieval != nil {
+			ctx = context.WithValue(ctx, citationNumbersKey{}, &citationNumbers{})
+		}
+		process := a.prov.ProcessMessage
+		if a.planner != nil {
+			process = func(ctx context.Context, yield func(provider.Result) bool, question string) error {
+				return a.planner.run(ctx, a.prov, yield, publish, question)
+			}
+		}
+		var err error
+		if a.retrieval != nil && a.retrieval.toolName == "" {
+			question, err = a.retrieval.augment(ctx, publish, question)
 		}
+		if err == nil {
+			if a.reflector != nil {
+				err = a.reflector.run(ctx, a, process, yield, publish, question)
+			} else {
+				err = process(ctx, yield, question)
+			}
+		}
+		switch {
+		case errors.Is(err, provider.ErrToolLimit):
+			finishReason = FinishReasonToolLimit
+		case err != nil:
+			a.logger.WarnContext(ctx, "run failed", "provider", provider.NameOf(a.prov), "error", err)
+			publish(NewErrorResult(err))
+			finishReason = FinishReasonError
+			if ctx.Err() != nil {
+				finishReason = FinishReasonCancelled
+			}
+		}
+		endRun(finishReason, answer, err)
+		runMetrics.end(finishReason)
+		a.logger.DebugContext(ctx, "run completed", "finish_reason", finishReason, "duration", time.Since(start))
+		publish(NewRunCompletedResult(finishReason))
 	}()
 
 	return subscriber, nil
 }
 
+// publisherKey is the context key of the function that publishes results of
+// the current run.
+type publisherKey struct{}
+
 // convertProviderResult converts a provider.Result to an agent Result.
-func convertProviderResult(providerResult provider.Result) (Result, error) {
+// onText, if not nil, is called with each non-empty text of a delta result.
+func convertProviderResult(providerResult provider.Result, onText func()) (Result, error) {
 	switch pr := providerResult.(type) {
 	case *provider.MessageResult:
 		return NewMessageResult(pr.GetText()), nil
 	case *provider.MessageDeltaResult:
-		return convertMessageDeltaResult(pr), nil
+		return convertMessageDeltaResult(pr, onText), nil
 	case *provider.ReasoningResult:
 		return NewReasoningResult(pr.GetText()), nil
 	case *provider.ReasoningDeltaResult:
-		return convertReasoningDeltaResult(pr), nil
+		return convertReasoningDeltaResult(pr, onText), nil
 	case *provider.FunctionCallResult:
 		return NewFunctionCallResult(pr.GetCallID(), pr.GetName(), pr.GetArguments()), nil
+	case *provider.FunctionCallOutputResult:
+		return NewFunctionCallOutputResult(pr.GetCallID(), pr.GetName(), pr.GetOutput()), nil
+	case *provider.ModelCallStartedResult:
+		return NewModelCallStartedResult(pr.GetModel()), nil
+	case *provider.ModelCallCompletedResult:
+		return NewModelCallCompletedResult(pr.GetModel(), pr.GetResponseID(), pr.GetFinishReason(), pr.GetUsage()), nil
+	case *provider.RetryResult:
+		return NewRetryResult(pr.GetAttempt(), pr.GetMaxAttempts(), pr.GetDelay(), pr.GetError()), nil
+	case *provider.ProviderSwitchedResult:
+		return NewProviderSwitchedResult(pr.GetFrom(), pr.GetTo(), pr.GetError()), nil
+	case *provider.RouteSelectedResult:
+		return NewRouteSelectedResult(pr.GetTarget(), pr.GetProvider(), pr.GetReason()), nil
+	case *provider.HandoffResult:
+		return NewHandoffResult(pr.GetFrom(), pr.GetTo(), pr.GetReason()), nil
 	default:
 		return nil, fmt.Errorf("unknown provider result type: %T", providerResult)
 	}
 }
 
 // convertMessageDeltaResult converts a provider MessageDeltaResult to an agent MessageDeltaResult.
-func convertMessageDeltaResult(pr *provider.MessageDeltaResult) *MessageDeltaResult {
+func convertMessageDeltaResult(pr *provider.MessageDeltaResult, onText func()) *MessageDeltaResult {
 	subscriber := util.NewSubscriber[string](1000)
 	agentResult := &MessageDeltaResult{
 		text:       "",
@@ -95,6 +220,9 @@ func convertMessageDeltaResult(pr *provider.MessageDeltaResult) *MessageDeltaRes
 	go func() {
 		defer subscriber.Close()
 		for delta := range pr.Subscribe() {
+			if delta != "" && onText != nil {
+				onText()
+			}
 			agentResult.text += delta
 			subscriber.Publish(delta)
 		}
@@ -104,7 +232,7 @@ func convertMessageDeltaResult(pr *provider.MessageDeltaResult) *MessageDeltaRes
 }
 
 // convertReasoningDeltaResult converts a provider ReasoningDeltaResult to an agent ReasoningDeltaResult.
-func convertReasoningDeltaResult(pr *provider.ReasoningDeltaResult) *ReasoningDeltaResult {
+func convertReasoningDeltaResult(pr *provider.ReasoningDeltaResult, onText func()) *ReasoningDeltaResult {
 	subscriber := util.NewSubscriber[string](1000)
 	agentResult := &ReasoningDeltaResult{
 		text:       "",
@@ -114,6 +242,9 @@ func convertReasoningDeltaResult(pr *provider.ReasoningDeltaResult) *ReasoningDe
 	go func() {
 		defer subscriber.Close()
 		for delta := range pr.Subscribe() {
+			if delta != "" && onText != nil {
+				onText()
+			}
 			agentResult.text += delta
 			subscriber.Publish(delta)
 		}
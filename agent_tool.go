@@ -0,0 +1,120 @@
+package orenoagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// AgentToolOption configures the tool returned by Agent.AsTool.
+type AgentToolOption func(*agentTool)
+
+// WithForwardResults publishes the sub-agent's results in the stream of the
+// agent that calls the tool, wrapped in SubAgentResult.
+// Default: false
+func WithForwardResults(forward bool) AgentToolOption {
+	return func(t *agentTool) {
+		t.forward = forward
+	}
+}
+
+type agentTool struct {
+	agent   *Agent
+	name    string
+	forward bool
+
+	// mu serializes invocations, which share the agent's provider.
+	mu sync.Mutex
+}
+
+// AsTool returns a tool that asks the agent the "input" argument and returns
+// its final message, so that another agent can delegate to it. Every
+// invocation starts a new conversation if the provider implements
+// provider.HistoryProvider. The agent should not be used for anything else
+// while it serves as a tool.
+//
+// Example usage:
+//
+//	researcher := orenoagent.NewAgent(researchProvider, orenoagent.WithTools(searchTools))
+//	coordinator := orenoagent.NewAgent(prov, orenoagent.WithTools([]orenoagent.Tool{
+//		researcher.AsTool("research", "Researches a topic and reports the findings.", orenoagent.WithForwardResults(true)),
+//	}))
+func (a *Agent) AsTool(name, description string, opts ...AgentToolOption) Tool {
+	t := &agentTool{
+		agent: a,
+		name:  name,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"input": map[string]any{
+					"type":        "string",
+					"description": "The task or question for the agent, with all the context it needs.",
+				},
+			},
+			"required": []string{"input"},
+		},
+		FunctionContext: t.call,
+	}
+}
+
+func (t *agentTool) call(ctx context.Context, arguments string) string {
+	var args struct {
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil || args.Input == "" {
+		args.Input = arguments
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if hp, ok := t.agent.prov.(provider.HistoryProvider); ok {
+		hp.SetHistory(nil)
+	}
+
+	// Forward before the sub-agent's Ask replaces the publisher in ctx.
+	forward := func(Result) {}
+	if parent, ok := ctx.Value(publisherKey{}).(func(Result) bool); ok && t.forward {
+		call, _ := provider.ToolCallFromContext(ctx)
+		forward = func(r Result) {
+			parent(NewSubAgentResult(call.CallID, t.name, r))
+		}
+	}
+
+	subscriber, err := t.agent.Ask(ctx, args.Input)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+
+	var answer string
+	var errs []string
+	for result := range subscriber.Subscribe() {
+		forward(result)
+		switch r := result.(type) {
+		case *MessageResult:
+			answer = r.String()
+		case *ErrorResult:
+			if err := r.Error(); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if answer == "" && len(errs) > 0 {
+		return "error: " + strings.Join(errs, "; ")
+	}
+	return answer
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+	"github.com/demouth/orenoagent-go/provider/gemini"
+	"github.com/demouth/orenoagent-go/provider/openai"
+	openaiSDK "github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+)
+
+// config holds the settings read from the config file and flags.
+type config struct {
+	// Provider is "openai" or "gemini".
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	// OpenAI only.
+	ReasoningSummary string `json:"reasoning_summary"`
+	ReasoningEffort  string `json:"reasoning_effort"`
+
+	// Gemini only.
+	IncludeThoughts bool   `json:"include_thoughts"`
+	ThinkingBudget  *int32 `json:"thinking_budget"`
+
+	MaxToolRounds int  `json:"max_tool_rounds"`
+	NoTools       bool `json:"no_tools"`
+}
+
+func loadConfig(path string) (config, error) {
+	cfg := config{Provider: "openai"}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// newAgent creates an Agent for the configured provider.
+func newAgent(ctx context.Context, cfg config) (*orenoagent.Agent, error) {
+	var prov provider.Provider
+	switch cfg.Provider {
+	case "openai":
+		opts := []openai.ProviderOption{openai.WithMaxToolRounds(cfg.MaxToolRounds)}
+		if cfg.Model != "" {
+			opts = append(opts, openai.WithModel(cfg.Model))
+		}
+		if cfg.ReasoningSummary != "" {
+			opts = append(opts, openai.WithReasoningSummary(cfg.ReasoningSummary))
+		}
+		if cfg.ReasoningEffort != "" {
+			opts = append(opts, openai.WithReasoningEffort(cfg.ReasoningEffort))
+		}
+		prov = openai.NewProvider(openaiSDK.NewClient(), opts...)
+	case "gemini":
+		client, err := genai.NewClient(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		opts := []gemini.ProviderOption{
+			gemini.WithMaxToolRounds(cfg.MaxToolRounds),
+			gemini.WithIncludeThoughts(cfg.IncludeThoughts),
+		}
+		if cfg.Model != "" {
+			opts = append(opts, gemini.WithModel(cfg.Model))
+		}
+		if cfg.ThinkingBudget != nil {
+			opts = append(opts, gemini.WithThinkingBudget(*cfg.ThinkingBudget))
+		}
+		prov = gemini.NewProvider(client, opts...)
+	default:
+		return nil, fmt.Errorf("unknown provider: %q", cfg.Provider)
+	}
+
+	var opts []orenoagent.AgentOption
+	if !cfg.NoTools {
+		opts = append(opts, orenoagent.WithTools(tools))
+	}
+	return orenoagent.NewAgent(prov, opts...), nil
+}
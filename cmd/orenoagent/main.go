@@ -0,0 +1,161 @@
+// Command orenoagent is an interactive chat with an OpenAI or Gemini agent.
+//
+// Usage:
+//
+//	orenoagent [flags]                  start an interactive session
+//	orenoagent -p [flags] [question]    answer one question and exit
+//
+// In one-shot mode the question is read from the arguments, or from stdin
+// when no arguments are given, and only the answer is written to stdout.
+//
+// Flags override the values of the JSON config file given with -config:
+//
+//	{"provider": "gemini", "model": "gemini-2.5-flash", "include_thoughts": true}
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "orenoagent: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		configPath       = flag.String("config", "", "path to a JSON config file")
+		providerName     = flag.String("provider", "", `provider to use: "openai" or "gemini" (default "openai")`)
+		model            = flag.String("model", "", "model name")
+		reasoningSummary = flag.String("reasoning-summary", "", `OpenAI reasoning summary: "auto", "concise" or "detailed"`)
+		reasoningEffort  = flag.String("reasoning-effort", "", `OpenAI reasoning effort: "none", "minimal", "low", "medium", "high" or "xhigh"`)
+		includeThoughts  = flag.Bool("include-thoughts", false, "Gemini: include thoughts in the response")
+		thinkingBudget   = flag.Int("thinking-budget", -1, "Gemini: thinking budget in tokens")
+		maxToolRounds    = flag.Int("max-tool-rounds", 0, "maximum number of tool rounds per question (0 = no limit)")
+		noTools          = flag.Bool("no-tools", false, "disable the built-in tools")
+		noColor          = flag.Bool("no-color", false, "disable colored output")
+		oneShot          = flag.Bool("p", false, "answer a single question from the arguments or stdin and exit")
+	)
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "provider":
+			cfg.Provider = *providerName
+		case "model":
+			cfg.Model = *model
+		case "reasoning-summary":
+			cfg.ReasoningSummary = *reasoningSummary
+		case "reasoning-effort":
+			cfg.ReasoningEffort = *reasoningEffort
+		case "include-thoughts":
+			cfg.IncludeThoughts = *includeThoughts
+		case "thinking-budget":
+			budget := int32(*thinkingBudget)
+			cfg.ThinkingBudget = &budget
+		case "max-tool-rounds":
+			cfg.MaxToolRounds = *maxToolRounds
+		case "no-tools":
+			cfg.NoTools = *noTools
+		}
+	})
+
+	if *oneShot {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		question := strings.Join(flag.Args(), " ")
+		if question == "" {
+			b, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			question = strings.TrimSpace(string(b))
+		}
+		if question == "" {
+			return fmt.Errorf("no question given")
+		}
+		return askOnce(ctx, cfg, question)
+	}
+
+	r := &repl{
+		cfg:   cfg,
+		in:    os.Stdin,
+		out:   os.Stdout,
+		style: newStyle(!*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)),
+	}
+	// Interrupts are only caught while a question is answered, so that
+	// Ctrl-C at the prompt quits.
+	return r.run(context.Background())
+}
+
+// askOnce prints the answer to question on stdout. Reasoning and tool calls
+// are not shown.
+func askOnce(ctx context.Context, cfg config, question string) error {
+	agent, err := newAgent(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	subscriber, err := agent.Ask(ctx, question)
+	if err != nil {
+		return err
+	}
+
+	var runErr error
+	printed := false
+	for result := range subscriber.Subscribe() {
+		switch r := result.(type) {
+		case *orenoagent.MessageDeltaResult:
+			for delta := range r.Subscribe() {
+				fmt.Print(delta)
+			}
+			printed = true
+		case *orenoagent.MessageResult:
+			if !printed {
+				fmt.Print(r.String())
+			}
+			printed = false
+			fmt.Println()
+		case *orenoagent.ErrorResult:
+			runErr = r.Error()
+		case *orenoagent.RunCompletedResult:
+			if runErr == nil && r.FinishReason() != orenoagent.FinishReasonStop {
+				runErr = fmt.Errorf("run finished: %s", r.FinishReason())
+			}
+		}
+	}
+	return runErr
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+var tools = []orenoagent.Tool{
+	{
+		Name:        "currentTime",
+		Description: "Get the current date and time with timezone in a human-readable format.",
+		Function: func(_ string) string {
+			return time.Now().Format(time.RFC3339)
+		},
+	},
+}
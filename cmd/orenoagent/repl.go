@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+const helpText = `Commands:
+  /reset          start a new conversation
+  /model [name]   show or switch the model
+  /save <file>    save the conversation as JSON Lines
+  /load <file>    load a conversation saved with /save
+  /export <file>  export the conversation as .md, .html or .jsonl
+  /tools          list the available tools
+  /help           show this help
+  /exit           quit`
+
+type repl struct {
+	cfg   config
+	in    io.Reader
+	out   io.Writer
+	style style
+
+	agent *orenoagent.Agent
+
+	// transcript holds every result of the conversation, used by /save and
+	// /export.
+	transcript []orenoagent.Result
+
+	// carryOver is earlier conversation text that the current agent has not
+	// seen, sent along with the next question after /load or /model.
+	carryOver string
+
+	// Number of delta results whose complete result has not arrived yet.
+	pendingMessages   int
+	pendingReasonings int
+}
+
+func (r *repl) run(ctx context.Context) error {
+	if err := r.reset(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(r.out, "orenoagent (%s) - type /help for commands\n", r.cfg.Provider)
+
+	scanner := bufio.NewScanner(r.in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(r.out, r.style.prompt("> "))
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			quit, err := r.command(ctx, line)
+			if err != nil {
+				fmt.Fprintln(r.out, r.style.error(err.Error()))
+			}
+			if quit {
+				return nil
+			}
+			continue
+		}
+
+		if err := r.ask(ctx, line); err != nil {
+			fmt.Fprintln(r.out, r.style.error(err.Error()))
+		}
+	}
+}
+
+// command runs a slash command. It returns true when the REPL should exit.
+func (r *repl) command(ctx context.Context, line string) (bool, error) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/exit", "/quit":
+		return true, nil
+
+	case "/help":
+		fmt.Fprintln(r.out, helpText)
+
+	case "/reset":
+		r.transcript = nil
+		r.carryOver = ""
+		if err := r.reset(ctx); err != nil {
+			return false, err
+		}
+		fmt.Fprintln(r.out, r.style.info("Started a new conversation."))
+
+	case "/model":
+		if arg == "" {
+			model := r.cfg.Model
+			if model == "" {
+				model = "(provider default)"
+			}
+			fmt.Fprintln(r.out, model)
+			return false, nil
+		}
+		previous := r.cfg.Model
+		r.cfg.Model = arg
+		if err := r.reset(ctx); err != nil {
+			r.cfg.Model = previous
+			return false, err
+		}
+		r.carryOver = renderConversation(r.transcript)
+		fmt.Fprintln(r.out, r.style.info("Switched model to "+arg+"."))
+
+	case "/save":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /save <file>")
+		}
+		if err := saveTranscript(arg, r.transcript); err != nil {
+			return false, err
+		}
+		fmt.Fprintln(r.out, r.style.info(fmt.Sprintf("Saved %d results to %s.", len(r.transcript), arg)))
+
+	case "/export":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /export <file>")
+		}
+		if err := exportTranscript(arg, r.transcript); err != nil {
+			return false, err
+		}
+		fmt.Fprintln(r.out, r.style.info("Exported the conversation to "+arg+"."))
+
+	case "/load":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /load <file>")
+		}
+		results, err := loadTranscript(arg)
+		if err != nil {
+			return false, err
+		}
+		if err := r.reset(ctx); err != nil {
+			return false, err
+		}
+		r.transcript = results
+		r.carryOver = renderConversation(results)
+		for _, result := range results {
+			if q, ok := result.(*orenoagent.RunStartedResult); ok {
+				fmt.Fprintln(r.out, r.style.prompt("> ")+q.Question())
+			}
+			r.print(result)
+		}
+		fmt.Fprintln(r.out, r.style.info("Loaded "+arg+"."))
+
+	case "/tools":
+		if r.cfg.NoTools || len(tools) == 0 {
+			fmt.Fprintln(r.out, "No tools.")
+			return false, nil
+		}
+		for _, t := range tools {
+			fmt.Fprintf(r.out, "%s\t%s\n", r.style.tool(t.Name), t.Description)
+		}
+
+	default:
+		return false, fmt.Errorf("unknown command: %s (type /help)", name)
+	}
+	return false, nil
+}
+
+func (r *repl) reset(ctx context.Context) error {
+	agent, err := newAgent(ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+	r.agent = agent
+	r.pendingMessages = 0
+	r.pendingReasonings = 0
+	return nil
+}
+
+// ask prints the answer to question. Ctrl-C cancels the answer and returns
+// to the prompt.
+func (r *repl) ask(ctx context.Context, question string) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// A cancelled answer may leave delta results without their complete
+	// result, which must not hide the complete results of this answer.
+	r.pendingMessages = 0
+	r.pendingReasonings = 0
+
+	input := question
+	if r.carryOver != "" {
+		input = "Conversation so far:\n\n" + r.carryOver + "Reply to the following message:\n\n" + question
+	}
+
+	subscriber, err := r.agent.Ask(ctx, input)
+	if err != nil {
+		return err
+	}
+	r.carryOver = ""
+
+	fmt.Fprintln(r.out)
+	for result := range subscriber.Subscribe() {
+		if _, ok := result.(*orenoagent.RunStartedResult); ok {
+			// Record the question as typed, without the carried-over context.
+			result = orenoagent.NewRunStartedResult(question)
+		}
+		r.transcript = append(r.transcript, result)
+		r.print(result)
+	}
+	return nil
+}
+
+// print renders a single result. Delta results are printed as they stream,
+// so the complete message or reasoning that follows them is skipped.
+func (r *repl) print(result orenoagent.Result) {
+	switch res := result.(type) {
+	case *orenoagent.MessageDeltaResult:
+		for delta := range res.Subscribe() {
+			fmt.Fprint(r.out, delta)
+		}
+		fmt.Fprint(r.out, "\n\n")
+		r.pendingMessages++
+	case *orenoagent.MessageResult:
+		if r.pendingMessages > 0 {
+			r.pendingMessages--
+			return
+		}
+		fmt.Fprint(r.out, res.String()+"\n\n")
+	case *orenoagent.ReasoningDeltaResult:
+		for delta := range res.Subscribe() {
+			fmt.Fprint(r.out, r.style.reasoning(delta))
+		}
+		fmt.Fprint(r.out, "\n\n")
+		r.pendingReasonings++
+	case *orenoagent.ReasoningResult:
+		if r.pendingReasonings > 0 {
+			r.pendingReasonings--
+			return
+		}
+		fmt.Fprint(r.out, r.style.reasoning(res.String())+"\n\n")
+	case *orenoagent.FunctionCallResult:
+		fmt.Fprintln(r.out, r.style.tool(res.String()))
+		fmt.Fprintln(r.out)
+	case *orenoagent.ErrorResult:
+		fmt.Fprintln(r.out, r.style.error(res.String()))
+	case *orenoagent.RunCompletedResult:
+		if res.FinishReason() != orenoagent.FinishReasonStop {
+			fmt.Fprintln(r.out, r.style.info("Finished: "+string(res.FinishReason())))
+		}
+	}
+}
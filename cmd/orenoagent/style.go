@@ -0,0 +1,23 @@
+package main
+
+// style applies ANSI colors to the output when enabled.
+type style struct {
+	enabled bool
+}
+
+func newStyle(enabled bool) style {
+	return style{enabled: enabled}
+}
+
+func (s style) wrap(code, text string) string {
+	if !s.enabled {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+func (s style) prompt(text string) string    { return s.wrap("1;32", text) }
+func (s style) reasoning(text string) string { return s.wrap("2;3", text) }
+func (s style) tool(text string) string      { return s.wrap("36", text) }
+func (s style) info(text string) string      { return s.wrap("2", text) }
+func (s style) error(text string) string     { return s.wrap("31", text) }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/transcript"
+)
+
+// saveTranscript writes results as JSON Lines.
+func saveTranscript(path string, results []orenoagent.Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if err := enc.Encode(result); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadTranscript reads results written by saveTranscript.
+func loadTranscript(path string) ([]orenoagent.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []orenoagent.Result
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		result, err := orenoagent.UnmarshalResult(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// exportTranscript writes results in the format given by the extension of
+// path: Markdown (.md), HTML (.html) or JSON Lines (.jsonl).
+func exportTranscript(path string, results []orenoagent.Result) error {
+	var write func(io.Writer, []orenoagent.Result) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		write = transcript.WriteMarkdown
+	case ".html", ".htm":
+		write = transcript.WriteHTML
+	case ".jsonl":
+		write = transcript.WriteJSONL
+	default:
+		return fmt.Errorf("unsupported export format %q: use .md, .html or .jsonl", filepath.Ext(path))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renderConversation renders the questions and answers of results as plain
+// text, so that a new agent can be given the earlier conversation.
+func renderConversation(results []orenoagent.Result) string {
+	var b strings.Builder
+	for _, result := range results {
+		switch r := result.(type) {
+		case *orenoagent.RunStartedResult:
+			fmt.Fprintf(&b, "[user]\n%s\n\n", r.Question())
+		case *orenoagent.MessageResult:
+			fmt.Fprintf(&b, "[assistant]\n%s\n\n", r.String())
+		}
+	}
+	return b.String()
+}
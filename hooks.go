@@ -0,0 +1,98 @@
+package orenoagent
+
+import (
+	"context"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// ModelCall is re-exported from provider for convenience.
+type ModelCall = provider.ModelCall
+
+// ToolCall is re-exported from provider for convenience.
+type ToolCall = provider.ToolCall
+
+// Hooks add cross-cutting behavior such as logging, redaction or argument
+// rewriting to an Agent. Any field may be nil.
+//
+// The model and tool hooks are run by providers that implement
+// provider.HooksProvider, which includes the OpenAI and Gemini providers and
+// the wrappers around them.
+type Hooks struct {
+	// BeforeModelCall is called before each request to the model, including
+	// retries. The returned context is used for the request and passed to
+	// AfterModelCall. An error aborts the run.
+	BeforeModelCall func(ctx context.Context, call ModelCall) (context.Context, error)
+
+	// AfterModelCall is called when a request has finished, even if it was
+	// aborted by BeforeModelCall. completed is nil if the request failed.
+	AfterModelCall func(ctx context.Context, call ModelCall, completed *ModelCallCompletedResult, err error)
+
+	// BeforeToolCall is called before a tool runs. It may change
+	// call.Arguments. The returned context is passed to AfterToolCall. An
+	// error vetoes the call; the model then receives the error message as
+	// the tool's output.
+	BeforeToolCall func(ctx context.Context, call *ToolCall) (context.Context, error)
+
+	// AfterToolCall is called after a tool has run or was vetoed, and
+	// returns the output sent to the model.
+	AfterToolCall func(ctx context.Context, call ToolCall, output string) string
+
+	// OnResult is called for every result before it is published and
+	// returns the result to publish instead. Returning nil drops the result.
+	OnResult func(ctx context.Context, result Result) Result
+}
+
+// WithHooks adds hooks to the agent. Hooks added by several WithHooks
+// options run in the order the options are given.
+//
+// Example usage:
+//
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithHooks(orenoagent.Hooks{
+//		BeforeToolCall: func(ctx context.Context, call *orenoagent.ToolCall) (context.Context, error) {
+//			log.Printf("tool %s(%s)", call.Name, call.Arguments)
+//			return ctx, nil
+//		},
+//	}))
+func WithHooks(hooks Hooks) AgentOption {
+	return func(a *Agent) {
+		a.hooks = append(a.hooks, hooks)
+	}
+}
+
+// providerHooks converts the agent's hooks for the provider.
+func (a *Agent) providerHooks() provider.Hooks {
+	var chain []provider.Hooks
+	for _, h := range a.hooks {
+		ph := provider.Hooks{
+			BeforeModelCall: h.BeforeModelCall,
+			BeforeToolCall:  h.BeforeToolCall,
+			AfterToolCall:   h.AfterToolCall,
+		}
+		if after := h.AfterModelCall; after != nil {
+			ph.AfterModelCall = func(ctx context.Context, call ModelCall, completed *provider.ModelCallCompletedResult, err error) {
+				var r *ModelCallCompletedResult
+				if completed != nil {
+					r = NewModelCallCompletedResult(completed.GetModel(), completed.GetResponseID(), completed.GetFinishReason(), completed.GetUsage())
+				}
+				after(ctx, call, r, err)
+			}
+		}
+		chain = append(chain, ph)
+	}
+	return provider.ChainHooks(chain...)
+}
+
+// onResult runs the OnResult hooks. It returns nil if a hook dropped the
+// result.
+func (a *Agent) onResult(ctx context.Context, result Result) Result {
+	for _, h := range a.hooks {
+		if h.OnResult == nil {
+			continue
+		}
+		if result = h.OnResult(ctx, result); result == nil {
+			return nil
+		}
+	}
+	return result
+}
@@ -0,0 +1,308 @@
+// Package httpagent exposes an orenoagent.Agent over HTTP as a stream of
+// Server-Sent Events.
+package httpagent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/internal/sse"
+)
+
+// Event names written to the SSE stream.
+const (
+	EventSession            = "session"
+	EventRunStarted         = "run_started"
+	EventModelCallStarted   = "model_call_started"
+	EventModelCallCompleted = "model_call_completed"
+	EventMessageDelta       = "message_delta"
+	EventMessage            = "message"
+	EventReasoningDelta     = "reasoning_delta"
+	EventReasoning          = "reasoning"
+	EventFunctionCall       = "function_call"
+	EventFunctionCallOutput = "function_call_output"
+	EventError              = "error"
+	EventRetry              = "retry"
+	EventProviderSwitched   = "provider_switched"
+	EventRouteSelected      = "route_selected"
+	EventHandoff            = "handoff"
+	EventPlan               = "plan"
+	EventPlanStep           = "plan_step"
+	EventCritique           = "critique"
+	EventCitations          = "citations"
+	EventSubAgent           = "sub_agent"
+	EventRunCompleted       = "run_completed"
+	EventDone               = "done"
+)
+
+// Request is the JSON body accepted by Handler.
+type Request struct {
+	Question string `json:"question"`
+
+	// SessionID continues an existing conversation. Leave empty to start a
+	// new session; its ID is sent back in the first "session" event.
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// Handler is an http.Handler that runs an Agent per session and streams its
+// results as Server-Sent Events.
+//
+//	POST   /  {"question": "...", "session_id": "..."}  streams results
+//	DELETE /?session_id=...                              ends a session
+type Handler struct {
+	newAgent func() *orenoagent.Agent
+
+	mu       sync.Mutex
+	sessions map[string]*session
+
+	// Sessions idle for longer than this are discarded. Zero means never.
+	sessionTTL time.Duration
+
+	// Maximum size of the request body in bytes.
+	maxBodySize int64
+}
+
+// session is guarded by Handler.mu.
+type session struct {
+	agent    *orenoagent.Agent
+	busy     bool
+	lastUsed time.Time
+}
+
+// HandlerOption configures a Handler.
+type HandlerOption func(*Handler)
+
+// WithSessionTTL discards sessions that have not been used for the given
+// duration. Default: 30 minutes.
+func WithSessionTTL(ttl time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.sessionTTL = ttl
+	}
+}
+
+// WithMaxBodySize sets the maximum accepted request body size in bytes.
+// Default: 1 MiB.
+func WithMaxBodySize(size int64) HandlerOption {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+// NewHandler creates a new Handler. newAgent is called once for every new
+// session, so each conversation gets its own Agent and provider history.
+//
+// Example usage:
+//
+//	handler := httpagent.NewHandler(func() *orenoagent.Agent {
+//		return orenoagent.NewAgent(openai.NewProvider(client), orenoagent.WithTools(tools))
+//	})
+//	http.Handle("/ask", handler)
+func NewHandler(newAgent func() *orenoagent.Agent, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		newAgent:    newAgent,
+		sessions:    map[string]*session{},
+		sessionTTL:  30 * time.Minute,
+		maxBodySize: 1 << 20,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.serveAsk(w, r)
+	case http.MethodDelete:
+		h.serveDelete(w, r)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) serveAsk(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodySize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Question == "" {
+		http.Error(w, "question is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	id, sess, status, err := h.acquire(req.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer h.release(sess)
+
+	ctx := r.Context()
+	subscriber, err := sess.agent.Ask(ctx, req.Question)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sw, _ := sse.NewWriter(w)
+	sw.Write(EventSession, map[string]string{"session_id": id})
+
+	// Keep draining after the client has gone away so that the agent's
+	// goroutines can finish; ctx cancellation stops the provider.
+	for result := range subscriber.Subscribe() {
+		switch res := result.(type) {
+		case *orenoagent.MessageDeltaResult:
+			for delta := range res.Subscribe() {
+				sw.Write(EventMessageDelta, map[string]string{"delta": delta})
+			}
+		case *orenoagent.ReasoningDeltaResult:
+			for delta := range res.Subscribe() {
+				sw.Write(EventReasoningDelta, map[string]string{"delta": delta})
+			}
+		default:
+			sw.Write(EventName(result), result)
+		}
+	}
+	sw.Write(EventDone, map[string]string{"session_id": id})
+}
+
+func (h *Handler) serveDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("session_id")
+
+	h.mu.Lock()
+	_, ok := h.sessions[id]
+	delete(h.sessions, id)
+	h.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// acquire marks the session for id as busy, creating a new session when id
+// is empty. On failure it returns the HTTP status to respond with.
+func (h *Handler) acquire(id string) (string, *session, int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expireSessions()
+
+	if id != "" {
+		sess, ok := h.sessions[id]
+		if !ok {
+			return "", nil, http.StatusNotFound, fmt.Errorf("session not found: %s", id)
+		}
+		if sess.busy {
+			return "", nil, http.StatusConflict, fmt.Errorf("session is busy: %s", id)
+		}
+		sess.busy = true
+		return id, sess, 0, nil
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return "", nil, http.StatusInternalServerError, err
+	}
+	sess := &session{
+		agent: h.newAgent(),
+		busy:  true,
+	}
+	h.sessions[id] = sess
+	return id, sess, 0, nil
+}
+
+func (h *Handler) release(sess *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sess.busy = false
+	sess.lastUsed = time.Now()
+}
+
+// expireSessions removes idle sessions. h.mu must be held.
+func (h *Handler) expireSessions() {
+	if h.sessionTTL <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-h.sessionTTL)
+	for id, sess := range h.sessions {
+		if !sess.busy && sess.lastUsed.Before(deadline) {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// EventName returns the SSE event name used for a result.
+func EventName(result orenoagent.Result) string {
+	switch result.(type) {
+	case *orenoagent.RunStartedResult:
+		return EventRunStarted
+	case *orenoagent.ModelCallStartedResult:
+		return EventModelCallStarted
+	case *orenoagent.ModelCallCompletedResult:
+		return EventModelCallCompleted
+	case *orenoagent.MessageDeltaResult:
+		return EventMessageDelta
+	case *orenoagent.MessageResult:
+		return EventMessage
+	case *orenoagent.ReasoningDeltaResult:
+		return EventReasoningDelta
+	case *orenoagent.ReasoningResult:
+		return EventReasoning
+	case *orenoagent.FunctionCallResult:
+		return EventFunctionCall
+	case *orenoagent.FunctionCallOutputResult:
+		return EventFunctionCallOutput
+	case *orenoagent.ErrorResult:
+		return EventError
+	case *orenoagent.RetryResult:
+		return EventRetry
+	case *orenoagent.ProviderSwitchedResult:
+		return EventProviderSwitched
+	case *orenoagent.RouteSelectedResult:
+		return EventRouteSelected
+	case *orenoagent.HandoffResult:
+		return EventHandoff
+	case *orenoagent.PlanResult:
+		return EventPlan
+	case *orenoagent.PlanStepResult:
+		return EventPlanStep
+	case *orenoagent.CritiqueResult:
+		return EventCritique
+	case *orenoagent.CitationsResult:
+		return EventCitations
+	case *orenoagent.SubAgentResult:
+		return EventSubAgent
+	case *orenoagent.RunCompletedResult:
+		return EventRunCompleted
+	default:
+		return result.Type()
+	}
+}
@@ -0,0 +1,264 @@
+package httpagent_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/httpagent"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// fakeProvider streams its reasoning and answer as deltas. The question
+// "wait" blocks until the run is cancelled.
+type fakeProvider struct {
+	mu        sync.Mutex
+	questions []string
+	cancelled chan struct{}
+}
+
+func (p *fakeProvider) SetTools([]provider.Tool) {}
+
+func (p *fakeProvider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	p.mu.Lock()
+	p.questions = append(p.questions, question)
+	p.mu.Unlock()
+
+	if question == "wait" {
+		yield(provider.NewModelCallStartedResult("test-model"))
+		<-ctx.Done()
+		close(p.cancelled)
+		return ctx.Err()
+	}
+
+	reasoning := provider.NewReasoningDeltaResult("Think")
+	yield(reasoning)
+	reasoning.AddDelta("ing.")
+	reasoning.Close()
+	yield(provider.NewReasoningResult("Thinking."))
+
+	message := provider.NewMessageDeltaResult("Hello")
+	yield(message)
+	message.AddDelta(", world")
+	message.Close()
+	yield(provider.NewMessageResult("Hello, world"))
+	return nil
+}
+
+type event struct {
+	name string
+	data map[string]any
+}
+
+// newTestServer returns a server whose sessions use the providers appended
+// to *providers.
+func newTestServer(t *testing.T) (*httptest.Server, *[]*fakeProvider) {
+	t.Helper()
+	var providers []*fakeProvider
+	srv := httptest.NewServer(httpagent.NewHandler(func() *orenoagent.Agent {
+		p := &fakeProvider{cancelled: make(chan struct{})}
+		providers = append(providers, p)
+		return orenoagent.NewAgent(p)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &providers
+}
+
+func post(ctx context.Context, t *testing.T, url string, req httpagent.Request) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// readEvents reads events until the stream ends or stop returns true.
+func readEvents(t *testing.T, resp *http.Response, stop func(event) bool) []event {
+	t.Helper()
+	var events []event
+	var name string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var data map[string]any
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+				t.Fatalf("event %s: %v", name, err)
+			}
+			events = append(events, event{name, data})
+			if stop != nil && stop(events[len(events)-1]) {
+				return events
+			}
+		}
+	}
+	return events
+}
+
+func ask(t *testing.T, url string, req httpagent.Request) []event {
+	t.Helper()
+	resp := post(context.Background(), t, url, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	return readEvents(t, resp, nil)
+}
+
+func TestEvents(t *testing.T) {
+	srv, _ := newTestServer(t)
+	events := ask(t, srv.URL, httpagent.Request{Question: "Hi"})
+
+	var names []string
+	var deltas strings.Builder
+	for _, e := range events {
+		names = append(names, e.name)
+		if e.name == httpagent.EventMessageDelta {
+			deltas.WriteString(e.data["delta"].(string))
+		}
+	}
+	want := []string{
+		httpagent.EventSession,
+		httpagent.EventRunStarted,
+		httpagent.EventReasoningDelta, httpagent.EventReasoningDelta,
+		httpagent.EventReasoning,
+		httpagent.EventMessageDelta, httpagent.EventMessageDelta,
+		httpagent.EventMessage,
+		httpagent.EventRunCompleted,
+		httpagent.EventDone,
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+	if deltas.String() != "Hello, world" {
+		t.Errorf("message deltas = %q", deltas.String())
+	}
+	if id := events[0].data["session_id"]; id == "" || id != events[len(events)-1].data["session_id"] {
+		t.Errorf("session IDs = %v and %v", id, events[len(events)-1].data["session_id"])
+	}
+	if got := events[7].data["text"]; got != "Hello, world" {
+		t.Errorf("message text = %v", got)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	srv, providers := newTestServer(t)
+
+	id := ask(t, srv.URL, httpagent.Request{Question: "Hi"})[0].data["session_id"].(string)
+	ask(t, srv.URL, httpagent.Request{Question: "Again", SessionID: id})
+	if len(*providers) != 1 || strings.Join((*providers)[0].questions, ",") != "Hi,Again" {
+		t.Fatalf("sessions did not share the agent: %d agents", len(*providers))
+	}
+
+	// A new session gets a new agent.
+	if other := ask(t, srv.URL, httpagent.Request{Question: "Hi"})[0].data["session_id"]; other == id {
+		t.Error("new session reused the session ID")
+	}
+	if len(*providers) != 2 {
+		t.Errorf("%d agents, want 2", len(*providers))
+	}
+
+	del := func(id string) int {
+		req, _ := http.NewRequest(http.MethodDelete, srv.URL+"?session_id="+id, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := del(id); status != http.StatusNoContent {
+		t.Errorf("DELETE = %d, want 204", status)
+	}
+	if status := del(id); status != http.StatusNotFound {
+		t.Errorf("second DELETE = %d, want 404", status)
+	}
+	resp := post(context.Background(), t, srv.URL, httpagent.Request{Question: "Hi", SessionID: id})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST to deleted session = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	srv, _ := newTestServer(t)
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"no question", http.MethodPost, `{}`, http.StatusBadRequest},
+		{"invalid JSON", http.MethodPost, `{`, http.StatusBadRequest},
+		{"unknown session", http.MethodPost, `{"question":"Hi","session_id":"nope"}`, http.StatusNotFound},
+		{"GET", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestClientDisconnect(t *testing.T) {
+	srv, providers := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := post(ctx, t, srv.URL, httpagent.Request{Question: "wait"})
+	events := readEvents(t, resp, func(e event) bool { return e.name == httpagent.EventModelCallStarted })
+	id := events[0].data["session_id"].(string)
+
+	// The session is busy while the question runs.
+	busy := post(context.Background(), t, srv.URL, httpagent.Request{Question: "Hi", SessionID: id})
+	busy.Body.Close()
+	if busy.StatusCode != http.StatusConflict {
+		t.Errorf("concurrent question = %d, want 409", busy.StatusCode)
+	}
+
+	// Going away cancels the run and frees the session.
+	cancel()
+	resp.Body.Close()
+	select {
+	case <-(*providers)[0].cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run was not cancelled")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := post(context.Background(), t, srv.URL, httpagent.Request{Question: "Hi", SessionID: id})
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session still unavailable: %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,60 @@
+// Package sse writes Server-Sent Events to HTTP responses.
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Writer writes events to an HTTP response, flushing after each one.
+type Writer struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	failed  bool
+}
+
+// NewWriter sets the headers of an event stream and writes the status line.
+// It returns false if w cannot be flushed, before writing anything.
+func NewWriter(w http.ResponseWriter) (*Writer, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	return &Writer{w: w, flusher: flusher}, true
+}
+
+// Write sends data encoded as JSON. event may be empty. Data that cannot be
+// encoded is replaced by an "error" event. After the first write error,
+// further writes are skipped.
+func (s *Writer) Write(event string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"error": err.Error()})
+		event = "error"
+	}
+	s.WriteData(event, string(b))
+}
+
+// WriteData sends data as is, such as the "[DONE]" message of OpenAI
+// streams. event may be empty.
+func (s *Writer) WriteData(event, data string) {
+	if s.failed {
+		return
+	}
+	var err error
+	if event != "" {
+		_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	} else {
+		_, err = fmt.Fprintf(s.w, "data: %s\n\n", data)
+	}
+	if err != nil {
+		s.failed = true
+		return
+	}
+	s.flusher.Flush()
+}
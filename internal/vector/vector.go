@@ -0,0 +1,32 @@
+// Package vector has the math of embedding vectors shared by the rag and
+// memory packages.
+package vector
+
+import "math"
+
+// Normalize returns v scaled to length 1, so that the cosine similarity of
+// normalized vectors is their dot product. A zero vector stays zero.
+func Normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if sum == 0 {
+		return out
+	}
+	norm := math.Sqrt(sum)
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// Dot returns the dot product of a and b, which must have the same length.
+func Dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,374 @@
+// Package mcp connects agents to Model Context Protocol servers: Client
+// imports the tools of an MCP server as provider.Tool values, and Server
+// publishes provider.Tool values to MCP clients.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Transport creates the transport to an MCP server. It is called for the
+// first connection and again for every reconnection.
+type Transport func() sdk.Transport
+
+// Command returns a Transport that starts the command and talks to it over
+// stdin and stdout.
+func Command(name string, args ...string) Transport {
+	return func() sdk.Transport {
+		return &sdk.CommandTransport{Command: exec.Command(name, args...)}
+	}
+}
+
+// StreamableHTTP returns a Transport that talks to the server at endpoint
+// with the streamable HTTP transport. httpClient may be nil to use
+// http.DefaultClient.
+func StreamableHTTP(endpoint string, httpClient *http.Client) Transport {
+	return func() sdk.Transport {
+		return &sdk.StreamableClientTransport{Endpoint: endpoint, HTTPClient: httpClient}
+	}
+}
+
+// Client is a connection to an MCP server. It reconnects when the connection
+// is lost and keeps its tools up to date when the server announces that they
+// changed. It is safe for concurrent use.
+type Client struct {
+	transport      Transport
+	prefix         string
+	callTimeout    time.Duration
+	onToolsChanged func(tools []provider.Tool)
+	logger         *slog.Logger
+
+	client *sdk.Client
+
+	// connectMu makes concurrent calls wait for a single reconnection.
+	connectMu sync.Mutex
+
+	mu      sync.Mutex
+	session *sdk.ClientSession
+	tools   []provider.Tool
+	closed  bool
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithToolPrefix prepends prefix to the names of the server's tools, to tell
+// apart tools of the same name from several servers.
+// Default: ""
+func WithToolPrefix(prefix string) ClientOption {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// WithCallTimeout sets how long a tool call may take.
+// Default: 60 seconds
+func WithCallTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.callTimeout = d
+	}
+}
+
+// WithToolsChanged sets a function called with the new tools after the
+// server changed its tool list. Use it to pass the tools on to a provider
+// between Asks.
+func WithToolsChanged(fn func(tools []provider.Tool)) ClientOption {
+	return func(c *Client) {
+		c.onToolsChanged = fn
+	}
+}
+
+// WithLogger sets the logger for connection events.
+// Default: a logger that discards everything
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// Connect connects to an MCP server and lists its tools.
+//
+// Example usage:
+//
+//	client, err := mcp.Connect(ctx, mcp.Command("my-mcp-server"))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithTools(client.Tools()))
+func Connect(ctx context.Context, transport Transport, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		transport:   transport,
+		callTimeout: 60 * time.Second,
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.client = sdk.NewClient(&sdk.Implementation{Name: "orenoagent"}, &sdk.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *sdk.ToolListChangedRequest) {
+			// The handler runs on the connection; list the tools outside it.
+			go c.toolsChanged()
+		},
+	})
+
+	if _, err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Tools returns the server's tools. Calling a tool sends the call to the
+// server, reconnecting first if needed.
+func (c *Client) Tools() []provider.Tool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.tools)
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	session := c.session
+	c.session = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	if session == nil {
+		return nil
+	}
+	return session.Close()
+}
+
+// connect opens a new session and refreshes the tools.
+func (c *Client) connect(ctx context.Context) (*sdk.ClientSession, error) {
+	session, err := c.client.Connect(ctx, c.transport(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: connect: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		session.Close()
+		return nil, errors.New("mcp: client is closed")
+	}
+	c.session = session
+	c.mu.Unlock()
+
+	go func() {
+		err := session.Wait()
+		c.mu.Lock()
+		lost := c.session == session
+		if lost {
+			c.session = nil
+		}
+		c.mu.Unlock()
+		if lost {
+			c.logger.Warn("mcp connection lost", "error", err)
+		}
+	}()
+
+	if err := c.refreshTools(ctx, session); err != nil {
+		c.mu.Lock()
+		if c.session == session {
+			c.session = nil
+		}
+		c.mu.Unlock()
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// currentSession returns the open session, reconnecting if the connection
+// was lost.
+func (c *Client) currentSession(ctx context.Context) (*sdk.ClientSession, error) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	c.mu.Lock()
+	session, closed := c.session, c.closed
+	c.mu.Unlock()
+
+	if closed {
+		return nil, errors.New("mcp: client is closed")
+	}
+	if session != nil {
+		return session, nil
+	}
+	c.logger.Info("mcp reconnecting")
+	return c.connect(ctx)
+}
+
+// dropSession forgets session so that the next call reconnects.
+func (c *Client) dropSession(session *sdk.ClientSession) {
+	c.mu.Lock()
+	if c.session == session {
+		c.session = nil
+	}
+	c.mu.Unlock()
+	session.Close()
+}
+
+func (c *Client) refreshTools(ctx context.Context, session *sdk.ClientSession) error {
+	var tools []provider.Tool
+	for t, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			return fmt.Errorf("mcp: list tools: %w", err)
+		}
+		tools = append(tools, c.convertTool(t))
+	}
+
+	c.mu.Lock()
+	c.tools = tools
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) toolsChanged() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.callTimeout)
+	defer cancel()
+
+	session, err := c.currentSession(ctx)
+	if err == nil {
+		err = c.refreshTools(ctx, session)
+	}
+	if err != nil {
+		c.logger.Warn("mcp failed to refresh tools", "error", err)
+		return
+	}
+	c.logger.Debug("mcp tools changed")
+	if c.onToolsChanged != nil {
+		c.onToolsChanged(c.Tools())
+	}
+}
+
+func (c *Client) convertTool(t *sdk.Tool) provider.Tool {
+	name := t.Name
+	return provider.Tool{
+		Name:        c.prefix + name,
+		Description: t.Description,
+		Parameters:  inputSchema(t.InputSchema),
+		FunctionContext: func(ctx context.Context, arguments string) string {
+			ctx, cancel := context.WithTimeout(ctx, c.callTimeout)
+			defer cancel()
+
+			output, err := c.CallTool(ctx, name, arguments)
+			if err != nil {
+				return fmt.Sprintf("error: %v", err)
+			}
+			return output
+		},
+	}
+}
+
+// CallTool calls the server's tool name, without the prefix, with arguments
+// as a JSON object and returns its output as text. If the connection was
+// lost, it reconnects and sends the call again.
+func (c *Client) CallTool(ctx context.Context, name, arguments string) (string, error) {
+	params := &sdk.CallToolParams{Name: name, Arguments: map[string]any{}}
+	if strings.TrimSpace(arguments) != "" {
+		if !json.Valid([]byte(arguments)) {
+			return "", fmt.Errorf("mcp: arguments of %s are not valid JSON", name)
+		}
+		params.Arguments = json.RawMessage(arguments)
+	}
+
+	for attempt := 0; ; attempt++ {
+		session, err := c.currentSession(ctx)
+		if err != nil {
+			return "", err
+		}
+		result, err := session.CallTool(ctx, params)
+		if connectionLost(err) && attempt == 0 && ctx.Err() == nil {
+			c.dropSession(session)
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("mcp: call %s: %w", name, err)
+		}
+		return resultText(result), nil
+	}
+}
+
+// connectionLost reports whether err means that the connection to the server
+// is gone, for example because the server process died.
+func connectionLost(err error) bool {
+	for _, target := range []error{sdk.ErrConnectionClosed, io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe, os.ErrClosed, net.ErrClosed, syscall.EPIPE, syscall.ECONNRESET} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputSchema converts a tool's input schema to the form of
+// provider.Tool.Parameters.
+func inputSchema(schema any) map[string]any {
+	if m, ok := schema.(map[string]any); ok {
+		return m
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return map[string]any{"type": "object"}
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil || m == nil {
+		return map[string]any{"type": "object"}
+	}
+	return m
+}
+
+// resultText renders the result of a tool call as text for the model.
+func resultText(result *sdk.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case *sdk.TextContent:
+			parts = append(parts, c.Text)
+		case *sdk.ImageContent:
+			parts = append(parts, fmt.Sprintf("[image %s, %d bytes]", c.MIMEType, len(c.Data)))
+		case *sdk.AudioContent:
+			parts = append(parts, fmt.Sprintf("[audio %s, %d bytes]", c.MIMEType, len(c.Data)))
+		case *sdk.ResourceLink:
+			parts = append(parts, fmt.Sprintf("[resource %s]", c.URI))
+		case *sdk.EmbeddedResource:
+			if c.Resource != nil && c.Resource.Text != "" {
+				parts = append(parts, c.Resource.Text)
+			} else if c.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource %s]", c.Resource.URI))
+			}
+		}
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			parts = append(parts, string(data))
+		}
+	}
+
+	text := strings.Join(parts, "\n")
+	if result.IsError && !strings.HasPrefix(text, "error:") {
+		return "error: " + text
+	}
+	return text
+}
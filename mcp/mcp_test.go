@@ -0,0 +1,226 @@
+package mcp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go/mcp"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// serverBin is the stdio server built from testdata/server.
+var serverBin string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "mcp-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	serverBin = filepath.Join(dir, "server")
+	if runtime.GOOS == "windows" {
+		serverBin += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", serverBin, "./testdata/server")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "build test server:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func connect(t *testing.T, opts ...mcp.ClientOption) *mcp.Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mcp.Connect(ctx, mcp.Command(serverBin), opts...)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func toolNames(tools []provider.Tool) []string {
+	var names []string
+	for _, t := range tools {
+		names = append(names, t.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestClientTools(t *testing.T) {
+	client := connect(t, mcp.WithToolPrefix("srv_"))
+
+	got := toolNames(client.Tools())
+	want := []string{"srv_add_tool", "srv_echo", "srv_fail", "srv_pid"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("tools = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		tool      string
+		arguments string
+		want      string
+	}{
+		{"srv_echo", `{"text":"hi"}`, `echo: {"text":"hi"}`},
+		{"srv_fail", `{}`, "error: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			tool, ok := provider.FindTool(client.Tools(), tt.tool)
+			if !ok {
+				t.Fatalf("tool %s not found", tt.tool)
+			}
+			if got := tool.Call(context.Background(), tt.arguments); got != tt.want {
+				t.Errorf("Call = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientToolsChanged(t *testing.T) {
+	changed := make(chan []provider.Tool, 1)
+	client := connect(t, mcp.WithToolsChanged(func(tools []provider.Tool) {
+		changed <- tools
+	}))
+
+	if _, err := client.CallTool(context.Background(), "add_tool", "{}"); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	select {
+	case tools := <-changed:
+		if !slices.Contains(toolNames(tools), "extra") {
+			t.Errorf("tools after change = %v, want extra", toolNames(tools))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("tools changed callback not called")
+	}
+	if !slices.Contains(toolNames(client.Tools()), "extra") {
+		t.Errorf("Tools() = %v, want extra", toolNames(client.Tools()))
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	client := connect(t)
+	ctx := context.Background()
+
+	before, err := client.CallTool(ctx, "pid", "")
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	pid, err := strconv.Atoi(before)
+	if err != nil {
+		t.Fatalf("pid = %q: %v", before, err)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatalf("find server: %v", err)
+	}
+	if err := process.Kill(); err != nil {
+		t.Fatalf("kill server: %v", err)
+	}
+
+	// The call after the crash reconnects, whether or not the client has
+	// noticed the lost connection yet.
+	after, err := client.CallTool(ctx, "pid", "")
+	if err != nil {
+		t.Fatalf("CallTool after kill: %v", err)
+	}
+	if after == before {
+		t.Errorf("pid after reconnect = %s, want a new process", after)
+	}
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	server := mcp.NewServer([]provider.Tool{
+		{
+			Name:        "add",
+			Description: "Adds two numbers.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"a": map[string]any{"type": "number"},
+					"b": map[string]any{"type": "number"},
+				},
+			},
+			Function: func(args string) string { return "sum of " + args },
+		},
+		{
+			Name:     "broken",
+			Function: func(string) string { return "error: not today" },
+		},
+		{
+			Name:     "panics",
+			Function: func(string) string { panic("oops") },
+		},
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	changed := make(chan []provider.Tool, 1)
+	ctx := context.Background()
+	client, err := mcp.Connect(ctx, mcp.StreamableHTTP(httpServer.URL, nil), mcp.WithToolsChanged(func(tools []provider.Tool) {
+		changed <- tools
+	}))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	if got, want := toolNames(client.Tools()), []string{"add", "broken", "panics"}; !slices.Equal(got, want) {
+		t.Fatalf("tools = %v, want %v", got, want)
+	}
+	add, _ := provider.FindTool(client.Tools(), "add")
+	if props, _ := add.Parameters["properties"].(map[string]any); len(props) != 2 {
+		t.Errorf("add parameters = %v, want properties a and b", add.Parameters)
+	}
+
+	tests := []struct {
+		tool      string
+		arguments string
+		want      string
+	}{
+		{"add", `{"a":1,"b":2}`, `sum of {"a":1,"b":2}`},
+		{"broken", `{}`, "error: not today"},
+	}
+	for _, tt := range tests {
+		got, err := client.CallTool(ctx, tt.tool, tt.arguments)
+		if err != nil {
+			t.Fatalf("CallTool(%s): %v", tt.tool, err)
+		}
+		if got != tt.want {
+			t.Errorf("CallTool(%s) = %q, want %q", tt.tool, got, tt.want)
+		}
+	}
+	if got, err := client.CallTool(ctx, "panics", `{}`); err != nil || !strings.HasPrefix(got, "error:") {
+		t.Errorf("CallTool(panics) = %q, %v, want an error output", got, err)
+	}
+
+	server.SetTools([]provider.Tool{{Name: "only", Function: func(string) string { return "only" }}})
+	select {
+	case tools := <-changed:
+		if got := toolNames(tools); !slices.Equal(got, []string{"only"}) {
+			t.Errorf("tools after SetTools = %v, want [only]", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("tools changed callback not called")
+	}
+}
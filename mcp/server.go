@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/provider"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Server publishes provider.Tool values to MCP clients over stdio or
+// streamable HTTP. It is safe for concurrent use.
+type Server struct {
+	name    string
+	version string
+
+	server  *sdk.Server
+	handler http.Handler
+
+	mu    sync.Mutex
+	names []string
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithServerName sets the name the server reports to clients.
+// Default: "orenoagent"
+func WithServerName(name string) ServerOption {
+	return func(s *Server) {
+		s.name = name
+	}
+}
+
+// WithServerVersion sets the version the server reports to clients.
+// Default: ""
+func WithServerVersion(version string) ServerOption {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// NewServer creates a Server publishing tools. A tool call runs
+// Tool.Function with the arguments as a JSON object and returns its output
+// as text content. An output starting with "error:" is reported as a tool
+// error.
+//
+// Example usage:
+//
+//	server := mcp.NewServer(tools)
+//	if err := server.ServeStdio(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+func NewServer(tools []provider.Tool, opts ...ServerOption) *Server {
+	s := &Server{
+		name: "orenoagent",
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server = sdk.NewServer(&sdk.Implementation{Name: s.name, Version: s.version}, &sdk.ServerOptions{
+		Capabilities: &sdk.ServerCapabilities{
+			// Advertise tools even when there are none yet, so that
+			// clients follow later SetTools calls.
+			Tools: &sdk.ToolCapabilities{ListChanged: true},
+		},
+	})
+	s.handler = sdk.NewStreamableHTTPHandler(func(*http.Request) *sdk.Server {
+		return s.server
+	}, nil)
+	s.SetTools(tools)
+	return s
+}
+
+// SetTools replaces the published tools. Connected clients are notified
+// that the tool list changed.
+func (s *Server) SetTools(tools []provider.Tool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for _, t := range tools {
+		s.server.AddTool(&sdk.Tool{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: objectSchema(t.Parameters),
+		}, toolHandler(t))
+		names = append(names, t.Name)
+	}
+
+	var removed []string
+	for _, name := range s.names {
+		if _, ok := provider.FindTool(tools, name); !ok {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		s.server.RemoveTools(removed...)
+	}
+	s.names = names
+}
+
+// ServeStdio serves a single client over stdin and stdout until the client
+// disconnects or ctx is cancelled.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.server.Run(ctx, &sdk.StdioTransport{})
+}
+
+// ServeHTTP serves clients with the streamable HTTP transport.
+//
+// Example usage:
+//
+//	http.Handle("/mcp", mcp.NewServer(tools))
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// toolHandler adapts a provider.Tool to an MCP tool handler.
+func toolHandler(t provider.Tool) sdk.ToolHandler {
+	return func(ctx context.Context, req *sdk.CallToolRequest) (*sdk.CallToolResult, error) {
+		arguments := "{}"
+		if len(req.Params.Arguments) > 0 && string(req.Params.Arguments) != "null" {
+			arguments = string(req.Params.Arguments)
+		}
+
+		// Tools without FunctionContext cannot be cancelled; stop waiting
+		// for them when the client gives up.
+		done := make(chan string, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- fmt.Sprintf("error: tool %s panicked: %v", t.Name, p)
+				}
+			}()
+			done <- t.Call(ctx, arguments)
+		}()
+
+		var output string
+		select {
+		case output = <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		text, isError := strings.CutPrefix(output, "error:")
+		if isError {
+			text = strings.TrimSpace(text)
+		}
+		return &sdk.CallToolResult{
+			Content: []sdk.Content{&sdk.TextContent{Text: text}},
+			IsError: isError,
+		}, nil
+	}
+}
+
+// objectSchema returns parameters as an MCP input schema, which must be a
+// JSON object schema.
+func objectSchema(parameters map[string]any) json.RawMessage {
+	schema := maps.Clone(parameters)
+	if schema == nil {
+		schema = map[string]any{}
+	}
+	schema["type"] = "object"
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return json.RawMessage(`{"type":"object"}`)
+	}
+	return data
+}
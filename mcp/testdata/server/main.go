@@ -0,0 +1,49 @@
+// Command server is an MCP stdio server used by the tests of package mcp.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/demouth/orenoagent-go/mcp"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+func main() {
+	var server *mcp.Server
+	tools := []provider.Tool{
+		{
+			Name:        "echo",
+			Description: "Returns its arguments.",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}},
+			Function:    func(args string) string { return "echo: " + args },
+		},
+		{
+			Name:        "pid",
+			Description: "Returns the process ID of the server.",
+			Function:    func(string) string { return strconv.Itoa(os.Getpid()) },
+		},
+		{
+			Name:        "fail",
+			Description: "Always fails.",
+			Function:    func(string) string { return "error: boom" },
+		},
+	}
+	tools = append(tools, provider.Tool{
+		Name:        "add_tool",
+		Description: "Publishes the extra tool.",
+		Function: func(string) string {
+			server.SetTools(append(tools, provider.Tool{
+				Name:     "extra",
+				Function: func(string) string { return "extra" },
+			}))
+			return "added"
+		},
+	})
+	server = mcp.NewServer(tools)
+	if err := server.ServeStdio(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/internal/vector"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// EmbeddingStore recalls memories by meaning: the query and the memories are
+// embedded, and the memories most similar to the query are returned.
+type EmbeddingStore struct {
+	embedder provider.Embedder
+	path     string
+
+	mu      sync.Mutex
+	entries []embeddingEntry
+}
+
+type embeddingEntry struct {
+	Memory Memory `json:"memory"`
+	// Vector is normalized, so that cosine similarity is a dot product.
+	Vector []float32 `json:"vector"`
+}
+
+// NewEmbeddingStore creates an EmbeddingStore that embeds with embedder. If
+// path is not empty, the memories and their vectors are loaded from and
+// saved to that JSON file.
+//
+// Example usage:
+//
+//	prov := openai.NewProvider(client)
+//	store, err := memory.NewEmbeddingStore(prov.(provider.Embedder), "memories.json")
+func NewEmbeddingStore(embedder provider.Embedder, path string) (*EmbeddingStore, error) {
+	s := &EmbeddingStore{
+		embedder: embedder,
+		path:     path,
+	}
+	if path != "" {
+		if err := readJSON(path, &s.entries); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add implements Store.
+func (s *EmbeddingStore) Add(ctx context.Context, m Memory) error {
+	v, err := s.embed(ctx, m.Text)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(append(slices.Clone(s.entries), embeddingEntry{Memory: m, Vector: v}))
+}
+
+// Search implements Store.
+func (s *EmbeddingStore) Search(ctx context.Context, namespace, query string, limit int) ([]Memory, error) {
+	var queryVector []float32
+	if query != "" {
+		var err error
+		if queryVector, err = s.embed(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type scored struct {
+		memory Memory
+		score  float64
+	}
+	var matches []scored
+	for _, e := range slices.Backward(s.entries) {
+		if e.Memory.Namespace != namespace {
+			continue
+		}
+		var score float64
+		if len(queryVector) == len(e.Vector) {
+			score = vector.Dot(queryVector, e.Vector)
+		}
+		if queryVector == nil || score > 0 {
+			matches = append(matches, scored{e.Memory, score})
+		}
+	}
+	if queryVector != nil {
+		slices.SortStableFunc(matches, func(a, b scored) int {
+			return cmp.Compare(b.score, a.score)
+		})
+	}
+
+	matches = matches[:min(max(limit, 0), len(matches))]
+	memories := make([]Memory, len(matches))
+	for i, m := range matches {
+		memories[i] = m.memory
+	}
+	return memories, nil
+}
+
+// List implements Store.
+func (s *EmbeddingStore) List(_ context.Context, namespace string) ([]Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var memories []Memory
+	for _, e := range s.entries {
+		if e.Memory.Namespace == namespace {
+			memories = append(memories, e.Memory)
+		}
+	}
+	return memories, nil
+}
+
+// Delete implements Store.
+func (s *EmbeddingStore) Delete(_ context.Context, namespace, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.entries, func(e embeddingEntry) bool {
+		return e.Memory.Namespace == namespace && e.Memory.ID == id
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	return s.save(slices.Delete(slices.Clone(s.entries), i, i+1))
+}
+
+// embed returns the normalized vector of text.
+func (s *EmbeddingStore) embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("memory: embed: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("memory: got %d vectors for one text", len(vectors))
+	}
+	return vector.Normalize(vectors[0]), nil
+}
+
+// save writes entries to the file, if there is one, and then makes them the
+// store's entries. s.mu must be held.
+func (s *EmbeddingStore) save(entries []embeddingEntry) error {
+	if s.path != "" {
+		if err := writeJSON(s.path, entries); err != nil {
+			return err
+		}
+	}
+	s.entries = entries
+	return nil
+}
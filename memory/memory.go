@@ -0,0 +1,266 @@
+// Package memory gives agents a long-term memory of facts about the user
+// that lasts across sessions. The model stores and looks up memories with
+// the remember, recall and forget tools, and the memories can be added to
+// the system prompt when a session starts.
+//
+// Memories are kept in a Store, scoped by a namespace such as a user ID.
+// MemoryStore keeps them in memory, FileStore in a JSON file and
+// EmbeddingStore recalls them by meaning with a provider.Embedder.
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Memory is a fact remembered about the user.
+type Memory struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrNotFound is returned when a memory does not exist.
+var ErrNotFound = errors.New("memory not found")
+
+// Store keeps memories. Implementations must be safe for concurrent use.
+type Store interface {
+	// Add stores a memory.
+	Add(ctx context.Context, m Memory) error
+
+	// Search returns at most limit memories of the namespace relevant to
+	// query, best first. An empty query returns the newest memories.
+	Search(ctx context.Context, namespace, query string, limit int) ([]Memory, error)
+
+	// List returns all memories of the namespace, oldest first.
+	List(ctx context.Context, namespace string) ([]Memory, error)
+
+	// Delete removes a memory, or returns ErrNotFound.
+	Delete(ctx context.Context, namespace, id string) error
+}
+
+// Scope is the memory of one user or namespace.
+type Scope struct {
+	store       Store
+	namespace   string
+	prefix      string
+	recallLimit int
+	promptLimit int
+}
+
+// ScopeOption configures a Scope.
+type ScopeOption func(*Scope)
+
+// WithToolPrefix is prepended to the names of the tools, for example to use
+// the memories of several namespaces in one agent.
+// Default: ""
+func WithToolPrefix(prefix string) ScopeOption {
+	return func(s *Scope) {
+		s.prefix = prefix
+	}
+}
+
+// WithRecallLimit sets how many memories the recall tool returns.
+// Default: 10
+func WithRecallLimit(n int) ScopeOption {
+	return func(s *Scope) {
+		s.recallLimit = n
+	}
+}
+
+// WithPromptLimit sets how many of the newest memories Instructions adds to
+// the system prompt.
+// Default: 20
+func WithPromptLimit(n int) ScopeOption {
+	return func(s *Scope) {
+		s.promptLimit = n
+	}
+}
+
+// NewScope returns the memory of namespace in store.
+//
+// Example usage:
+//
+//	store, err := memory.NewFileStore("memories.json")
+//	mem := memory.NewScope(store, "user:"+userID)
+//	instructions, err := mem.Instructions(ctx, "You are a helpful assistant.")
+//	agent := orenoagent.NewAgent(
+//		openai.NewProvider(client, openai.WithInstructions(instructions)),
+//		orenoagent.WithTools(append(tools, mem.Tools()...)),
+//	)
+func NewScope(store Store, namespace string, opts ...ScopeOption) *Scope {
+	s := &Scope{
+		store:       store,
+		namespace:   namespace,
+		recallLimit: 10,
+		promptLimit: 20,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Remember stores text as a new memory.
+func (s *Scope) Remember(ctx context.Context, text string) (Memory, error) {
+	m := Memory{
+		ID:        newID(),
+		Namespace: s.namespace,
+		Text:      strings.TrimSpace(text),
+		CreatedAt: time.Now(),
+	}
+	if m.Text == "" {
+		return Memory{}, errors.New("memory: empty text")
+	}
+	if err := s.store.Add(ctx, m); err != nil {
+		return Memory{}, err
+	}
+	return m, nil
+}
+
+// Recall returns the memories relevant to query, best first.
+func (s *Scope) Recall(ctx context.Context, query string) ([]Memory, error) {
+	return s.store.Search(ctx, s.namespace, query, s.recallLimit)
+}
+
+// Forget removes a memory.
+func (s *Scope) Forget(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, s.namespace, id)
+}
+
+const instructionsFormat = `%s
+
+You have a long-term memory of the user. Use the %s tool to store lasting facts and preferences the user shares, %s to look up memories, and %s to delete memories that are wrong or that the user asks you to forget.`
+
+const rememberedFormat = `
+
+What you remember about the user:
+%s`
+
+// Instructions returns base extended with how to use the memory tools and
+// the newest memories, to be used as the system prompt of a new session.
+func (s *Scope) Instructions(ctx context.Context, base string) (string, error) {
+	prompt := fmt.Sprintf(instructionsFormat, base, s.prefix+"remember", s.prefix+"recall", s.prefix+"forget")
+
+	memories, err := s.store.Search(ctx, s.namespace, "", s.promptLimit)
+	if err != nil {
+		return "", err
+	}
+	if len(memories) > 0 {
+		prompt += fmt.Sprintf(rememberedFormat, formatMemories(memories))
+	}
+	return strings.TrimSpace(prompt), nil
+}
+
+// Tools returns the remember, recall and forget tools.
+func (s *Scope) Tools() []provider.Tool {
+	return []provider.Tool{
+		{
+			Name:        s.prefix + "remember",
+			Description: "Stores a lasting fact about the user, such as a preference or a personal detail, so that it is known in later conversations. Store one fact per call.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"text": map[string]any{
+						"type":        "string",
+						"description": "The fact, as a short self-contained sentence.",
+					},
+				},
+				"required": []string{"text"},
+			},
+			FunctionContext: func(ctx context.Context, arguments string) string {
+				var args struct {
+					Text string `json:"text"`
+				}
+				if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				m, err := s.Remember(ctx, args.Text)
+				if err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				return fmt.Sprintf("Remembered (id: %s).", m.ID)
+			},
+		},
+		{
+			Name:        s.prefix + "recall",
+			Description: "Looks up memories about the user relevant to a query. Each memory is shown with its id.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{
+						"type":        "string",
+						"description": "What to look for. Empty returns the newest memories.",
+					},
+				},
+			},
+			FunctionContext: func(ctx context.Context, arguments string) string {
+				var args struct {
+					Query string `json:"query"`
+				}
+				if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				memories, err := s.Recall(ctx, args.Query)
+				if err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				if len(memories) == 0 {
+					return "No memories found."
+				}
+				return formatMemories(memories)
+			},
+		},
+		{
+			Name:        s.prefix + "forget",
+			Description: "Deletes a memory that is wrong or that the user asks you to forget. Find its id with recall first.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "string",
+						"description": "The id of the memory.",
+					},
+				},
+				"required": []string{"id"},
+			},
+			FunctionContext: func(ctx context.Context, arguments string) string {
+				var args struct {
+					ID string `json:"id"`
+				}
+				if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				if err := s.Forget(ctx, args.ID); err != nil {
+					return fmt.Sprintf("error: %v", err)
+				}
+				return "Forgotten."
+			},
+		},
+	}
+}
+
+func formatMemories(memories []Memory) string {
+	var b strings.Builder
+	for _, m := range memories {
+		fmt.Fprintf(&b, "- [%s] %s (%s)\n", m.ID, m.Text, m.CreatedAt.Format(time.DateOnly))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
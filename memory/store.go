@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/demouth/orenoagent-go/internal/atomicfile"
+)
+
+// MemoryStore keeps memories in memory and recalls them by the words they
+// share with the query.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	memories []Memory
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, m Memory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memories = append(s.memories, m)
+	return nil
+}
+
+// Search implements Store. Memories are ranked by how many words of the
+// query they contain, newer first among equals.
+func (s *MemoryStore) Search(_ context.Context, namespace, query string, limit int) ([]Memory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := keywords(query)
+	type scored struct {
+		memory Memory
+		score  int
+	}
+	var matches []scored
+	for _, m := range slices.Backward(s.memories) {
+		if m.Namespace != namespace {
+			continue
+		}
+		score := 0
+		text := strings.ToLower(m.Text)
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				score++
+			}
+		}
+		if len(words) == 0 || score > 0 {
+			matches = append(matches, scored{m, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	matches = matches[:min(max(limit, 0), len(matches))]
+	memories := make([]Memory, len(matches))
+	for i, m := range matches {
+		memories[i] = m.memory
+	}
+	return memories, nil
+}
+
+// keywords returns the lowercased words of query, ignoring one-letter words.
+func keywords(query string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(w)) > 1 && !slices.Contains(words, w) {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, namespace string) ([]Memory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var memories []Memory
+	for _, m := range s.memories {
+		if m.Namespace == namespace {
+			memories = append(memories, m)
+		}
+	}
+	return memories, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, namespace, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memories, err := deleteMemory(s.memories, namespace, id)
+	if err != nil {
+		return err
+	}
+	s.memories = memories
+	return nil
+}
+
+// deleteMemory removes the memory with the given namespace and ID from
+// memories, in place.
+func deleteMemory(memories []Memory, namespace, id string) ([]Memory, error) {
+	i := slices.IndexFunc(memories, func(m Memory) bool {
+		return m.Namespace == namespace && m.ID == id
+	})
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	return slices.Delete(memories, i, i+1), nil
+}
+
+// all returns a copy of all memories.
+func (s *MemoryStore) all() []Memory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.memories)
+}
+
+// set replaces all memories.
+func (s *MemoryStore) set(memories []Memory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memories = memories
+}
+
+// FileStore is a MemoryStore that is saved to a JSON file after every
+// change. A change that cannot be saved is not made.
+type FileStore struct {
+	MemoryStore
+	path string
+
+	// mu serializes changes with the writes of the file.
+	mu sync.Mutex
+}
+
+// NewFileStore creates a FileStore, loading the memories in path if the file
+// exists.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	if err := readJSON(path, &s.memories); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add implements Store.
+func (s *FileStore) Add(_ context.Context, m Memory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(append(s.all(), m))
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(_ context.Context, namespace, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	memories, err := deleteMemory(s.all(), namespace, id)
+	if err != nil {
+		return err
+	}
+	return s.save(memories)
+}
+
+// save writes memories to the file and then makes them the store's
+// memories. s.mu must be held.
+func (s *FileStore) save(memories []Memory) error {
+	if err := writeJSON(s.path, memories); err != nil {
+		return err
+	}
+	s.set(memories)
+	return nil
+}
+
+// readJSON decodes the file at path into v. A missing file is not an error.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("memory: load %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON replaces the file at path atomically with v encoded as JSON.
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data)
+}
@@ -0,0 +1,169 @@
+package orenoagent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/demouth/orenoagent-go/metrics"
+)
+
+// Metric names recorded by WithMetrics.
+const (
+	metricRunsStarted       = "orenoagent_runs_started_total"
+	metricRunsCompleted     = "orenoagent_runs_completed_total"
+	metricRunsFailed        = "orenoagent_runs_failed_total"
+	metricRunDuration       = "orenoagent_run_duration_seconds"
+	metricModelCallDuration = "orenoagent_model_call_duration_seconds"
+	metricTimeToFirstToken  = "orenoagent_model_time_to_first_token_seconds"
+	metricModelCallRetries  = "orenoagent_model_call_retries_total"
+	metricTokens            = "orenoagent_tokens_total"
+	metricToolCalls         = "orenoagent_tool_calls_total"
+	metricToolCallDuration  = "orenoagent_tool_call_duration_seconds"
+)
+
+// WithMetrics records metrics about runs, model calls and tool calls:
+//
+//   - orenoagent_runs_started_total
+//   - orenoagent_runs_completed_total{finish_reason}
+//   - orenoagent_runs_failed_total
+//   - orenoagent_run_duration_seconds
+//   - orenoagent_model_call_duration_seconds{model}
+//   - orenoagent_model_time_to_first_token_seconds{model}
+//   - orenoagent_model_call_retries_total{model}
+//   - orenoagent_tokens_total{model,type} with type input, output, cached or reasoning
+//   - orenoagent_tool_calls_total{tool}
+//   - orenoagent_tool_call_duration_seconds{tool}
+//
+// Tool metrics need a provider that implements provider.HooksProvider.
+//
+// Example usage:
+//
+//	registry := metrics.NewRegistry()
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithMetrics(registry))
+func WithMetrics(m metrics.Metrics) AgentOption {
+	return func(a *Agent) {
+		a.metrics = m
+	}
+}
+
+// runMetrics records the metrics of one Ask. A nil *runMetrics records
+// nothing.
+type runMetrics struct {
+	m     metrics.Metrics
+	start time.Time
+
+	// State of the current model call. Delta texts arrive on other
+	// goroutines, so mu guards it.
+	mu         sync.Mutex
+	call       int
+	model      string
+	callStart  time.Time
+	firstToken bool
+}
+
+func (a *Agent) startRunMetrics() *runMetrics {
+	if a.metrics == nil {
+		return nil
+	}
+	a.metrics.Add(metricRunsStarted, 1)
+	return &runMetrics{m: a.metrics, start: time.Now()}
+}
+
+// observe updates the model call metrics with a result of the run.
+func (rm *runMetrics) observe(result Result) {
+	if rm == nil {
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	switch r := result.(type) {
+	case *ModelCallStartedResult:
+		rm.call++
+		rm.model = r.Model()
+		rm.callStart = time.Now()
+		rm.firstToken = false
+	case *MessageResult, *ReasoningResult, *FunctionCallResult:
+		// Delta results may start empty, so their first token is the first
+		// non-empty text, recorded by firstTokenFunc.
+		rm.recordFirstToken(rm.call)
+	case *RetryResult:
+		rm.m.Add(metricModelCallRetries, 1, metrics.Label{Name: "model", Value: rm.model})
+	case *ModelCallCompletedResult:
+		model := metrics.Label{Name: "model", Value: rm.model}
+		rm.m.Observe(metricModelCallDuration, time.Since(rm.callStart).Seconds(), model)
+		usage := r.Usage()
+		for _, t := range []struct {
+			name   string
+			tokens int
+		}{
+			{"input", usage.InputTokens},
+			{"output", usage.OutputTokens},
+			{"cached", usage.CachedTokens},
+			{"reasoning", usage.ReasoningTokens},
+		} {
+			if t.tokens > 0 {
+				rm.m.Add(metricTokens, float64(t.tokens), model, metrics.Label{Name: "type", Value: t.name})
+			}
+		}
+	}
+}
+
+// firstTokenFunc returns a function that records the time to first token of
+// the current model call, for the texts of delta results. It returns nil if
+// rm is nil.
+func (rm *runMetrics) firstTokenFunc() func() {
+	if rm == nil {
+		return nil
+	}
+	rm.mu.Lock()
+	call := rm.call
+	rm.mu.Unlock()
+	return func() {
+		rm.mu.Lock()
+		defer rm.mu.Unlock()
+		rm.recordFirstToken(call)
+	}
+}
+
+// recordFirstToken records the time to first token if call is the current
+// model call and it has no first token yet. rm.mu must be held.
+func (rm *runMetrics) recordFirstToken(call int) {
+	if call != rm.call || rm.firstToken || rm.callStart.IsZero() {
+		return
+	}
+	rm.firstToken = true
+	rm.m.Observe(metricTimeToFirstToken, time.Since(rm.callStart).Seconds(), metrics.Label{Name: "model", Value: rm.model})
+}
+
+func (rm *runMetrics) end(finishReason FinishReason) {
+	if rm == nil {
+		return
+	}
+	rm.m.Add(metricRunsCompleted, 1, metrics.Label{Name: "finish_reason", Value: string(finishReason)})
+	if finishReason == FinishReasonError {
+		rm.m.Add(metricRunsFailed, 1)
+	}
+	rm.m.Observe(metricRunDuration, time.Since(rm.start).Seconds())
+}
+
+type toolStartKey struct{}
+
+// metricsHooks returns hooks that record tool call metrics.
+func (a *Agent) metricsHooks() Hooks {
+	return Hooks{
+		BeforeToolCall: func(ctx context.Context, call *ToolCall) (context.Context, error) {
+			return context.WithValue(ctx, toolStartKey{}, time.Now()), nil
+		},
+		AfterToolCall: func(ctx context.Context, call ToolCall, output string) string {
+			tool := metrics.Label{Name: "tool", Value: call.Name}
+			a.metrics.Add(metricToolCalls, 1, tool)
+			if start, ok := ctx.Value(toolStartKey{}).(time.Time); ok {
+				a.metrics.Observe(metricToolCallDuration, time.Since(start).Seconds(), tool)
+			}
+			return output
+		},
+	}
+}
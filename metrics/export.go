@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes all metrics in the Prometheus text exposition
+// format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, name := range slices.Sorted(maps.Keys(r.families)) {
+		f := r.families[name]
+		typ := "counter"
+		if f.kind == kindHistogram {
+			typ = "histogram"
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, typ)
+
+		for _, key := range slices.Sorted(maps.Keys(f.series)) {
+			s := f.series[key]
+			if f.kind == kindCounter {
+				fmt.Fprintf(bw, "%s%s %s\n", name, formatLabels(s.labels), formatValue(s.value))
+				continue
+			}
+			for i, upper := range r.buckets {
+				labels := append(slices.Clone(s.labels), Label{"le", formatValue(upper)})
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", name, formatLabels(labels), s.buckets[i])
+			}
+			labels := append(slices.Clone(s.labels), Label{"le", "+Inf"})
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, formatLabels(labels), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", name, formatLabels(s.labels), formatValue(s.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", name, formatLabels(s.labels), s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format, so that a
+// Registry can be mounted as the /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
+
+// Expvar returns an expvar.Var reporting all metrics as JSON.
+//
+// Example usage:
+//
+//	expvar.Publish("orenoagent", registry.Expvar())
+func (r *Registry) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		out := map[string]any{}
+		for name, f := range r.families {
+			var values []map[string]any
+			for _, key := range slices.Sorted(maps.Keys(f.series)) {
+				s := f.series[key]
+				v := map[string]any{}
+				if len(s.labels) > 0 {
+					labels := map[string]string{}
+					for _, l := range s.labels {
+						labels[l.Name] = l.Value
+					}
+					v["labels"] = labels
+				}
+				if f.kind == kindCounter {
+					v["value"] = s.value
+				} else {
+					v["count"] = s.count
+					v["sum"] = s.value
+					buckets := map[string]uint64{}
+					for i, upper := range r.buckets {
+						buckets[formatValue(upper)] = s.buckets[i]
+					}
+					v["buckets"] = buckets
+				}
+				values = append(values, v)
+			}
+			out[name] = values
+		}
+		return out
+	})
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(l.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,163 @@
+// Package metrics collects counters and histograms about agent runs and
+// exports them in the Prometheus text format or through expvar, without any
+// external service.
+package metrics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Label is a name-value pair that distinguishes series of a metric.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics receives measurements. Implement it to forward measurements to
+// another metrics library.
+type Metrics interface {
+	// Add adds delta to a counter.
+	Add(name string, delta float64, labels ...Label)
+
+	// Observe records a value in a histogram.
+	Observe(name string, value float64, labels ...Label)
+}
+
+// DefaultBuckets are the histogram upper bounds used by default, suitable
+// for latencies in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+type kind int
+
+const (
+	kindCounter kind = iota
+	kindHistogram
+)
+
+// Registry is an in-memory Metrics implementation. It is safe for
+// concurrent use.
+type Registry struct {
+	buckets []float64
+
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	kind   kind
+	series map[string]*series
+}
+
+type series struct {
+	labels []Label
+
+	// value is the counter value, or the sum of observed values.
+	value float64
+
+	// count and buckets are used by histograms. buckets[i] counts the
+	// observations less than or equal to Registry.buckets[i].
+	count   uint64
+	buckets []uint64
+}
+
+// RegistryOption configures a Registry.
+type RegistryOption func(*Registry)
+
+// WithBuckets sets the histogram upper bounds.
+// Default: DefaultBuckets
+func WithBuckets(buckets ...float64) RegistryOption {
+	return func(r *Registry) {
+		r.buckets = slices.Sorted(slices.Values(buckets))
+	}
+}
+
+// NewRegistry creates a new Registry.
+//
+// Example usage:
+//
+//	registry := metrics.NewRegistry()
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithMetrics(registry))
+//	http.Handle("/metrics", registry)
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		buckets:  DefaultBuckets,
+		families: map[string]*family{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Add implements Metrics.
+func (r *Registry) Add(name string, delta float64, labels ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.series(name, kindCounter, labels)
+	if s == nil {
+		return
+	}
+	s.value += delta
+}
+
+// Observe implements Metrics.
+func (r *Registry) Observe(name string, value float64, labels ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.series(name, kindHistogram, labels)
+	if s == nil {
+		return
+	}
+	s.value += value
+	s.count++
+	for i, upper := range r.buckets {
+		if value <= upper {
+			s.buckets[i]++
+		}
+	}
+}
+
+// series returns the series of a metric, creating it if needed. It returns
+// nil if the name is already used by a metric of another kind.
+func (r *Registry) series(name string, k kind, labels []Label) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: k, series: map[string]*series{}}
+		r.families[name] = f
+	}
+	if f.kind != k {
+		return nil
+	}
+
+	labels = slices.Clone(labels)
+	slices.SortFunc(labels, func(a, b Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	key := labelKey(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		if k == kindHistogram {
+			s.buckets = make([]uint64, len(r.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func labelKey(labels []Label) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.Name)
+		b.WriteByte(0)
+		b.WriteString(l.Value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
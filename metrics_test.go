@@ -0,0 +1,71 @@
+package orenoagent_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/metrics"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// observations records the values observed per metric name.
+type observations struct {
+	mu     sync.Mutex
+	values map[string][]float64
+}
+
+func (o *observations) Add(string, float64, ...metrics.Label) {}
+
+func (o *observations) Observe(name string, value float64, _ ...metrics.Label) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.values[name] = append(o.values[name], value)
+}
+
+// slowDeltaProvider starts its answer with an empty delta, as the OpenAI
+// client does, and sends the first text after a delay.
+type slowDeltaProvider struct {
+	delay time.Duration
+}
+
+func (p *slowDeltaProvider) SetTools([]provider.Tool) {}
+func (p *slowDeltaProvider) ProcessMessage(_ context.Context, yield func(provider.Result) bool, _ string) error {
+	yield(provider.NewModelCallStartedResult("test-model"))
+	delta := provider.NewMessageDeltaResult("")
+	yield(delta)
+	time.Sleep(p.delay)
+	delta.AddDelta("Hello")
+	delta.Close()
+	yield(provider.NewMessageResult("Hello"))
+	yield(provider.NewModelCallCompletedResult("test-model", "resp_1", provider.FinishReasonStop, provider.Usage{}))
+	return nil
+}
+
+func TestTimeToFirstToken(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	m := &observations{values: map[string][]float64{}}
+	agent := orenoagent.NewAgent(&slowDeltaProvider{delay: delay}, orenoagent.WithMetrics(m))
+	subscriber, err := agent.Ask(context.Background(), "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for result := range subscriber.Subscribe() {
+		if r, ok := result.(*orenoagent.MessageDeltaResult); ok {
+			for range r.Subscribe() {
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ttft := m.values["orenoagent_model_time_to_first_token_seconds"]
+	if len(ttft) != 1 {
+		t.Fatalf("time to first token observed %d times, want 1", len(ttft))
+	}
+	if ttft[0] < delay.Seconds() {
+		t.Errorf("time to first token = %fs, want at least %fs", ttft[0], delay.Seconds())
+	}
+}
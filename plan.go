@@ -0,0 +1,240 @@
+package orenoagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// PlanOption configures planning mode.
+type PlanOption func(*planner)
+
+// WithMaxPlanSteps limits the number of steps of a plan. Longer plans are
+// truncated.
+// Default: 10
+func WithMaxPlanSteps(n int) PlanOption {
+	return func(p *planner) {
+		p.maxSteps = n
+	}
+}
+
+// WithMaxReplans limits how many times the agent may replan after failed
+// steps while answering one question.
+// Default: 2
+func WithMaxReplans(n int) PlanOption {
+	return func(p *planner) {
+		p.maxReplans = n
+	}
+}
+
+// WithPlanning enables planning mode. The agent first asks the model for a
+// plan and publishes it as a PlanResult, then runs every step with the tools,
+// publishing a PlanStepResult whenever a step starts, succeeds or fails.
+// When a step fails, the model revises the remaining steps and a new
+// PlanResult is published. Finally the model answers the question from the
+// results of the steps.
+//
+// Example usage:
+//
+//	agent := orenoagent.NewAgent(provider,
+//		orenoagent.WithTools(tools),
+//		orenoagent.WithPlanning(orenoagent.WithMaxReplans(3)),
+//	)
+func WithPlanning(opts ...PlanOption) AgentOption {
+	return func(a *Agent) {
+		p := &planner{
+			maxSteps:   10,
+			maxReplans: 2,
+		}
+		for _, opt := range opts {
+			opt(p)
+		}
+		a.planner = p
+	}
+}
+
+type planner struct {
+	maxSteps   int
+	maxReplans int
+}
+
+// stepFailedPrefix starts the answer of a step the model could not complete.
+const stepFailedPrefix = "FAILED:"
+
+const planPrompt = `Before answering, make a plan for the task below. Reply only with JSON of the form {"steps": ["first step", "second step"]}, with at most %d short, self-contained steps that can be done with the available tools. Do not do the steps yet.
+
+Task:
+%s`
+
+const replanPrompt = `Step %d of the plan failed: %s
+
+Steps done so far:
+%s
+Revise the plan for the remaining work of the task. Reply only with JSON of the form {"steps": ["next step", "step after"]}, with at most %d steps. Reply with {"steps": []} if the task cannot be completed.`
+
+const stepPrompt = `Do step %d of the plan: %s
+
+Use the tools as needed and reply with the result of this step only. If the step cannot be done, reply with "` + stepFailedPrefix + `" followed by the reason.`
+
+const answerPrompt = `All steps are finished. Using their results, answer the original task:
+%s`
+
+// run answers question by planning and then running the steps one by one.
+// Every model call goes through the provider, so the tool loop, hooks and
+// history work as without planning.
+func (p *planner) run(ctx context.Context, prov provider.Provider, yield func(provider.Result) bool, publish func(Result) bool, question string) error {
+	descriptions, err := p.plan(ctx, prov, yield, fmt.Sprintf(planPrompt, p.maxSteps, question))
+	if err != nil {
+		return err
+	}
+
+	var done []PlanStep
+	steps := newPlanSteps(descriptions)
+	if !publish(NewPlanResult(steps)) {
+		return errors.New("cancelled")
+	}
+
+	for replans := 0; ; {
+		failed := -1
+		for i := range steps {
+			steps[i].Status = StepRunning
+			if !publish(NewPlanStepResult(i, steps[i])) {
+				return errors.New("cancelled")
+			}
+
+			output, err := p.step(ctx, prov, yield, i, steps[i].Description)
+			switch {
+			case errors.Is(err, provider.ErrToolLimit):
+				steps[i].Status = StepFailed
+				steps[i].Output = err.Error()
+			case err != nil:
+				return err
+			case strings.HasPrefix(output, stepFailedPrefix):
+				steps[i].Status = StepFailed
+				steps[i].Output = strings.TrimSpace(strings.TrimPrefix(output, stepFailedPrefix))
+			default:
+				steps[i].Status = StepDone
+				steps[i].Output = output
+			}
+			if !publish(NewPlanStepResult(i, steps[i])) {
+				return errors.New("cancelled")
+			}
+
+			if steps[i].Status == StepFailed {
+				failed = i
+				break
+			}
+			done = append(done, steps[i])
+		}
+
+		if failed < 0 {
+			break
+		}
+		if replans >= p.maxReplans {
+			return fmt.Errorf("plan: step %d failed after %d replans: %s", failed+1, replans, steps[failed].Output)
+		}
+		replans++
+
+		descriptions, err := p.plan(ctx, prov, yield, fmt.Sprintf(replanPrompt, failed+1, steps[failed].Output, formatSteps(done), p.maxSteps))
+		if err != nil {
+			return err
+		}
+		if len(descriptions) == 0 {
+			return fmt.Errorf("plan: step %d failed and no new plan was made: %s", failed+1, steps[failed].Output)
+		}
+		steps = newPlanSteps(descriptions)
+		if !publish(NewPlanResult(steps)) {
+			return errors.New("cancelled")
+		}
+	}
+
+	return prov.ProcessMessage(ctx, yield, fmt.Sprintf(answerPrompt, question))
+}
+
+// plan asks the model for the steps of a plan. Its messages are not
+// published; the plan is published as a PlanResult instead.
+func (p *planner) plan(ctx context.Context, prov provider.Provider, yield func(provider.Result) bool, prompt string) ([]string, error) {
+	var text string
+	err := prov.ProcessMessage(ctx, func(r provider.Result) bool {
+		switch r := r.(type) {
+		case *provider.MessageResult:
+			text += r.GetText()
+			return true
+		case *provider.MessageDeltaResult:
+			return true
+		}
+		return yield(r)
+	}, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := parsePlan(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) > p.maxSteps {
+		steps = steps[:p.maxSteps]
+	}
+	return steps, nil
+}
+
+// step runs one step and returns the model's answer to it.
+func (p *planner) step(ctx context.Context, prov provider.Provider, yield func(provider.Result) bool, index int, description string) (string, error) {
+	var output string
+	err := prov.ProcessMessage(ctx, func(r provider.Result) bool {
+		if m, ok := r.(*provider.MessageResult); ok {
+			output += m.GetText()
+		}
+		return yield(r)
+	}, fmt.Sprintf(stepPrompt, index+1, description))
+	return strings.TrimSpace(output), err
+}
+
+// parsePlan reads the steps from the model's reply, which may wrap the JSON
+// in text or a code block.
+func parsePlan(text string) ([]string, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("plan: no JSON in reply: %q", text)
+	}
+
+	var v struct {
+		Steps []string `json:"steps"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &v); err != nil {
+		return nil, fmt.Errorf("plan: invalid JSON in reply: %w", err)
+	}
+
+	steps := make([]string, 0, len(v.Steps))
+	for _, s := range v.Steps {
+		if s = strings.TrimSpace(s); s != "" {
+			steps = append(steps, s)
+		}
+	}
+	return steps, nil
+}
+
+func newPlanSteps(descriptions []string) []PlanStep {
+	steps := make([]PlanStep, len(descriptions))
+	for i, d := range descriptions {
+		steps[i] = PlanStep{Description: d, Status: StepPending}
+	}
+	return steps
+}
+
+func formatSteps(steps []PlanStep) string {
+	if len(steps) == 0 {
+		return "(none)\n"
+	}
+	var b strings.Builder
+	for i, s := range steps {
+		fmt.Fprintf(&b, "%d. %s\n   Result: %s\n", i+1, s.Description, s.Output)
+	}
+	return b.String()
+}
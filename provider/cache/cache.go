@@ -0,0 +1,213 @@
+// Package cache provides a provider.Provider that records answers and
+// replays them when the same question is asked again in the same situation,
+// which saves time and money in evaluation runs and demos.
+package cache
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+type bypassKey struct{}
+
+// Bypass returns a context that makes the cache ignore stored answers for
+// one question. The fresh answer still replaces the stored one.
+//
+// Example usage:
+//
+//	subscriber, err := agent.Ask(cache.Bypass(ctx), question)
+func Bypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func isBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// Provider answers from a Store when it has seen the same request before,
+// and asks the wrapped provider otherwise.
+//
+// A request is identified by the wrapped provider's name, its instructions,
+// the conversation history, the tools and the question. The wrapped
+// provider must implement provider.HistoryProvider; otherwise every
+// question is passed through uncached. Tools are not run again when an
+// answer is replayed.
+type Provider struct {
+	inner provider.HistoryProvider
+	store Store
+	tools []provider.Tool
+
+	ttl    time.Duration
+	pacing bool
+}
+
+// ProviderOption configures a cache Provider.
+type ProviderOption func(*Provider)
+
+// WithTTL sets how long stored answers are used. Zero (the default) means
+// forever.
+func WithTTL(ttl time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithPacing replays stored answers at the speed they were first streamed,
+// instead of all at once.
+func WithPacing(pacing bool) ProviderOption {
+	return func(p *Provider) {
+		p.pacing = pacing
+	}
+}
+
+// NewProvider creates a new cache provider wrapping inner.
+//
+// Example usage:
+//
+//	provider := cache.NewProvider(openai.NewProvider(client), cache.NewMemoryStore(1000))
+//	provider := cache.NewProvider(openai.NewProvider(client), cache.NewDiskStore(".cache"), cache.WithTTL(24*time.Hour))
+func NewProvider(inner provider.Provider, store Store, opts ...ProviderOption) provider.Provider {
+	hp, ok := inner.(provider.HistoryProvider)
+	if !ok {
+		return inner
+	}
+
+	p := &Provider{
+		inner: hp,
+		store: store,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// ProcessMessage implements provider.Provider. Store errors are treated as
+// cache misses.
+func (p *Provider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	history := p.inner.History()
+	key, err := p.key(history, question)
+	if err != nil {
+		return p.inner.ProcessMessage(ctx, yield, question)
+	}
+
+	if !isBypassed(ctx) {
+		if e, ok := p.lookup(key); ok {
+			if err := replay(ctx, yield, e.Events, p.pacing); err != nil {
+				return err
+			}
+			p.inner.SetHistory(append(history, fromMessages(e.History)...))
+			return nil
+		}
+	}
+
+	rec := newRecorder()
+	err = p.inner.ProcessMessage(ctx, func(r provider.Result) bool {
+		return yield(rec.record(r))
+	}, question)
+	if err != nil {
+		return err
+	}
+
+	e := entry{
+		CreatedAt: time.Now(),
+		Events:    rec.finish(),
+	}
+	if added := p.inner.History(); len(added) >= len(history) {
+		e.History = toMessages(added[len(history):])
+	}
+	if data, err := json.Marshal(e); err == nil {
+		p.store.Set(key, data)
+	}
+	return nil
+}
+
+func (p *Provider) lookup(key string) (entry, bool) {
+	data, ok, err := p.store.Get(key)
+	if err != nil || !ok {
+		return entry{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return entry{}, false
+	}
+	if p.ttl > 0 && time.Since(e.CreatedAt) > p.ttl {
+		return entry{}, false
+	}
+	return e, true
+}
+
+// key returns a hash of everything that affects the answer.
+func (p *Provider) key(history []provider.Message, question string) (string, error) {
+	type tool struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+	}
+	request := struct {
+		Provider     string    `json:"provider"`
+		Instructions string    `json:"instructions"`
+		History      []message `json:"history"`
+		Tools        []tool    `json:"tools"`
+		Question     string    `json:"question"`
+	}{
+		Provider: provider.NameOf(p.inner),
+		History:  toMessages(history),
+		Question: question,
+	}
+	if ip, ok := p.inner.(provider.InstructionsProvider); ok {
+		request.Instructions = ip.Instructions()
+	}
+	for _, t := range p.tools {
+		request.Tools = append(request.Tools, tool{t.Name, t.Description, t.Parameters})
+	}
+	slices.SortFunc(request.Tools, func(a, b tool) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	// encoding/json sorts map keys, so the encoding is canonical.
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SetTools implements provider.Provider.
+func (p *Provider) SetTools(tools []provider.Tool) {
+	p.tools = tools
+	p.inner.SetTools(tools)
+}
+
+// SetHooks implements provider.HooksProvider.
+func (p *Provider) SetHooks(hooks provider.Hooks) {
+	if hp, ok := p.inner.(provider.HooksProvider); ok {
+		hp.SetHooks(hooks)
+	}
+}
+
+// Name implements provider.Namer.
+func (p *Provider) Name() string {
+	return provider.NameOf(p.inner)
+}
+
+// History implements provider.HistoryProvider.
+func (p *Provider) History() []provider.Message {
+	return p.inner.History()
+}
+
+// SetHistory implements provider.HistoryProvider.
+func (p *Provider) SetHistory(history []provider.Message) {
+	p.inner.SetHistory(history)
+}
@@ -0,0 +1,265 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"github.com/demouth/orenoagent-go/provider/cache"
+)
+
+// fakeProvider streams a fixed answer with a tool call and keeps a history.
+type fakeProvider struct {
+	name         string
+	instructions string
+	history      []provider.Message
+	calls        int
+}
+
+func (p *fakeProvider) Name() string                          { return p.name }
+func (p *fakeProvider) Instructions() string                  { return p.instructions }
+func (p *fakeProvider) SetTools([]provider.Tool)              {}
+func (p *fakeProvider) History() []provider.Message           { return slices.Clone(p.history) }
+func (p *fakeProvider) SetHistory(history []provider.Message) { p.history = slices.Clone(history) }
+func (p *fakeProvider) ProcessMessage(_ context.Context, yield func(provider.Result) bool, question string) error {
+	p.calls++
+	yield(provider.NewModelCallStartedResult("test-model"))
+	yield(provider.NewFunctionCallResult("call_1", "clock", `{"zone":"UTC"}`))
+	yield(provider.NewFunctionCallOutputResult("call_1", "clock", "12:00"))
+	delta := provider.NewMessageDeltaResult("It is ")
+	yield(delta)
+	delta.AddDelta(fmt.Sprintf("12:00 (answer %d).", p.calls))
+	delta.Close()
+	yield(provider.NewMessageResult(delta.GetText()))
+	yield(provider.NewModelCallCompletedResult("test-model", "resp_1", provider.FinishReasonStop, provider.Usage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10}))
+	p.history = append(p.history,
+		provider.Message{Role: provider.RoleUser, Text: question},
+		provider.Message{Role: provider.RoleAssistant, Text: delta.GetText()},
+	)
+	return nil
+}
+
+// ask sends question and returns the results it yielded in a comparable
+// form, with delta results reduced to their whole text.
+func ask(t *testing.T, ctx context.Context, p provider.Provider, question string) []string {
+	t.Helper()
+	var results []provider.Result
+	if err := p.ProcessMessage(ctx, func(r provider.Result) bool {
+		results = append(results, r)
+		return true
+	}, question); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+
+	var got []string
+	for _, r := range results {
+		switch r := r.(type) {
+		case *provider.MessageDeltaResult:
+			var text strings.Builder
+			for delta := range r.Subscribe() {
+				text.WriteString(delta)
+			}
+			got = append(got, "message_delta: "+text.String())
+		case *provider.MessageResult:
+			got = append(got, "message: "+r.GetText())
+		case *provider.FunctionCallResult:
+			got = append(got, fmt.Sprintf("function_call: %s %s %s", r.GetCallID(), r.GetName(), r.GetArguments()))
+		case *provider.FunctionCallOutputResult:
+			got = append(got, fmt.Sprintf("function_call_output: %s %s", r.GetCallID(), r.GetOutput()))
+		case *provider.ModelCallStartedResult:
+			got = append(got, "model_call_started: "+r.GetModel())
+		case *provider.ModelCallCompletedResult:
+			got = append(got, fmt.Sprintf("model_call_completed: %s %s %s %+v", r.GetModel(), r.GetResponseID(), r.GetFinishReason(), r.GetUsage()))
+		default:
+			got = append(got, r.Type())
+		}
+	}
+	return got
+}
+
+func TestReplay(t *testing.T) {
+	store := cache.NewMemoryStore(0)
+	ctx := context.Background()
+
+	first := &fakeProvider{name: "fake:a"}
+	want := ask(t, ctx, cache.NewProvider(first, store), "What time is it?")
+
+	// A new conversation in the same situation is answered from the cache,
+	// with the same results and the same history.
+	second := &fakeProvider{name: "fake:a"}
+	got := ask(t, ctx, cache.NewProvider(second, store), "What time is it?")
+	if second.calls != 0 {
+		t.Errorf("inner provider called %d times, want 0", second.calls)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("replayed results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if fmt.Sprint(second.history) != fmt.Sprint(first.history) {
+		t.Errorf("history after replay = %v, want %v", second.history, first.history)
+	}
+
+	// Bypass asks again and stores the new answer.
+	third := &fakeProvider{name: "fake:a", calls: 1}
+	fresh := ask(t, cache.Bypass(ctx), cache.NewProvider(third, store), "What time is it?")
+	if third.calls != 2 || slices.Equal(fresh, want) {
+		t.Fatalf("bypass: calls = %d, results = %v", third.calls, fresh)
+	}
+	fourth := &fakeProvider{name: "fake:a"}
+	if got := ask(t, ctx, cache.NewProvider(fourth, store), "What time is it?"); !slices.Equal(got, fresh) {
+		t.Errorf("after bypass, replayed %v, want the fresh answer %v", got, fresh)
+	}
+}
+
+func TestPacedReplay(t *testing.T) {
+	store := cache.NewMemoryStore(0)
+	ctx := context.Background()
+	want := ask(t, ctx, cache.NewProvider(&fakeProvider{name: "fake:a"}, store), "Hi")
+	if got := ask(t, ctx, cache.NewProvider(&fakeProvider{name: "fake:a"}, store, cache.WithPacing(true)), "Hi"); !slices.Equal(got, want) {
+		t.Errorf("paced replay = %v, want %v", got, want)
+	}
+}
+
+func TestKey(t *testing.T) {
+	clock := provider.Tool{Name: "clock", Description: "Tells the time.", Parameters: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"zone": map[string]any{"type": "string"}, "format": map[string]any{"type": "string"}},
+	}}
+	weather := provider.Tool{Name: "weather", Description: "Tells the weather."}
+	base := func() (*fakeProvider, []provider.Tool) {
+		return &fakeProvider{name: "fake:a", instructions: "Be brief."}, []provider.Tool{clock, weather}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(p *fakeProvider, tools []provider.Tool) []provider.Tool
+		question string
+		hit      bool
+	}{
+		{"same request", nil, "Hi", true},
+		{"tools in another order", func(_ *fakeProvider, _ []provider.Tool) []provider.Tool {
+			return []provider.Tool{weather, clock}
+		}, "Hi", true},
+		{"same parameters in a new map", func(_ *fakeProvider, _ []provider.Tool) []provider.Tool {
+			c := clock
+			c.Parameters = map[string]any{
+				"properties": map[string]any{"format": map[string]any{"type": "string"}, "zone": map[string]any{"type": "string"}},
+				"type":       "object",
+			}
+			return []provider.Tool{c, weather}
+		}, "Hi", true},
+		{"other question", nil, "Hello", false},
+		{"other provider", func(p *fakeProvider, tools []provider.Tool) []provider.Tool {
+			p.name = "fake:b"
+			return tools
+		}, "Hi", false},
+		{"other instructions", func(p *fakeProvider, tools []provider.Tool) []provider.Tool {
+			p.instructions = "Be verbose."
+			return tools
+		}, "Hi", false},
+		{"other history", func(p *fakeProvider, tools []provider.Tool) []provider.Tool {
+			p.history = []provider.Message{{Role: provider.RoleUser, Text: "Earlier"}}
+			return tools
+		}, "Hi", false},
+		{"other tool description", func(_ *fakeProvider, _ []provider.Tool) []provider.Tool {
+			w := weather
+			w.Description = "Tells the forecast."
+			return []provider.Tool{clock, w}
+		}, "Hi", false},
+		{"tool removed", func(_ *fakeProvider, _ []provider.Tool) []provider.Tool {
+			return []provider.Tool{clock}
+		}, "Hi", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache.NewMemoryStore(0)
+			ctx := context.Background()
+
+			inner, tools := base()
+			p := cache.NewProvider(inner, store)
+			p.SetTools(tools)
+			ask(t, ctx, p, "Hi")
+
+			inner, tools = base()
+			if tt.change != nil {
+				tools = tt.change(inner, tools)
+			}
+			p = cache.NewProvider(inner, store)
+			p.SetTools(tools)
+			ask(t, ctx, p, tt.question)
+			if hit := inner.calls == 0; hit != tt.hit {
+				t.Errorf("cache hit = %v, want %v", hit, tt.hit)
+			}
+		})
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		hit  bool
+	}{
+		{"forever", 0, true},
+		{"fresh", time.Hour, true},
+		{"expired", time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache.NewMemoryStore(0)
+			ctx := context.Background()
+			ask(t, ctx, cache.NewProvider(&fakeProvider{name: "fake:a"}, store, cache.WithTTL(tt.ttl)), "Hi")
+			time.Sleep(5 * time.Millisecond)
+
+			inner := &fakeProvider{name: "fake:a"}
+			ask(t, ctx, cache.NewProvider(inner, store, cache.WithTTL(tt.ttl)), "Hi")
+			if hit := inner.calls == 0; hit != tt.hit {
+				t.Errorf("cache hit = %v, want %v", hit, tt.hit)
+			}
+		})
+	}
+}
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		store cache.Store
+	}{
+		{"memory", cache.NewMemoryStore(0)},
+		{"disk", cache.NewDiskStore(filepath.Join(t.TempDir(), "cache"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok, err := tt.store.Get("missing"); ok || err != nil {
+				t.Errorf("Get(missing) = %v, %v, want a miss", ok, err)
+			}
+			for _, value := range []string{"one", "two"} {
+				if err := tt.store.Set("key", []byte(value)); err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+				got, ok, err := tt.store.Get("key")
+				if err != nil || !ok || string(got) != value {
+					t.Errorf("Get = %q, %v, %v, want %q", got, ok, err, value)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	store := cache.NewMemoryStore(2)
+	store.Set("a", []byte("a"))
+	store.Set("b", []byte("b"))
+	store.Get("a") // a is now the most recently used
+	store.Set("c", []byte("c"))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := store.Get(key); ok != want {
+			t.Errorf("Get(%s) found = %v, want %v", key, ok, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// entry is a recorded answer.
+type entry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Events    []event   `json:"events"`
+
+	// History holds the messages the answer added to the conversation.
+	History []message `json:"history"`
+}
+
+// Kinds of recorded events.
+const (
+	eventResult = "result"
+	eventDelta  = "delta"
+	eventClose  = "close"
+)
+
+// event is one step of a recorded answer. Offset is the time since the
+// question was sent. Delta and close events refer to the delta result with
+// index Stream among the recorded results.
+type event struct {
+	Kind   string        `json:"kind"`
+	Offset time.Duration `json:"offset"`
+	Result *result       `json:"result,omitempty"`
+	Stream int           `json:"stream,omitempty"`
+	Delta  string        `json:"delta,omitempty"`
+}
+
+// result is a provider.Result in serializable form.
+type result struct {
+	Type         string                `json:"type"`
+	Text         string                `json:"text,omitempty"`
+	CallID       string                `json:"call_id,omitempty"`
+	Name         string                `json:"name,omitempty"`
+	Arguments    string                `json:"arguments,omitempty"`
+	Output       string                `json:"output,omitempty"`
+	Model        string                `json:"model,omitempty"`
+	ResponseID   string                `json:"response_id,omitempty"`
+	FinishReason provider.FinishReason `json:"finish_reason,omitempty"`
+	Usage        *provider.Usage       `json:"usage,omitempty"`
+}
+
+// message is a provider.Message in serializable form.
+type message struct {
+	Role          provider.Role           `json:"role"`
+	Text          string                  `json:"text,omitempty"`
+	FunctionCalls []provider.FunctionCall `json:"function_calls,omitempty"`
+	CallID        string                  `json:"call_id,omitempty"`
+	Name          string                  `json:"name,omitempty"`
+}
+
+func toMessages(history []provider.Message) []message {
+	messages := make([]message, 0, len(history))
+	for _, m := range history {
+		messages = append(messages, message(m))
+	}
+	return messages
+}
+
+func fromMessages(messages []message) []provider.Message {
+	history := make([]provider.Message, 0, len(messages))
+	for _, m := range messages {
+		history = append(history, provider.Message(m))
+	}
+	return history
+}
+
+// encodeResult converts r for storage. It returns nil for results that are
+// not part of the answer, such as retries.
+func encodeResult(r provider.Result) *result {
+	switch r := r.(type) {
+	case *provider.MessageResult:
+		return &result{Type: r.Type(), Text: r.GetText()}
+	case *provider.MessageDeltaResult:
+		return &result{Type: r.Type(), Text: r.GetText()}
+	case *provider.ReasoningResult:
+		return &result{Type: r.Type(), Text: r.GetText()}
+	case *provider.ReasoningDeltaResult:
+		return &result{Type: r.Type(), Text: r.GetText()}
+	case *provider.FunctionCallResult:
+		return &result{Type: r.Type(), CallID: r.GetCallID(), Name: r.GetName(), Arguments: r.GetArguments()}
+	case *provider.FunctionCallOutputResult:
+		return &result{Type: r.Type(), CallID: r.GetCallID(), Name: r.GetName(), Output: r.GetOutput()}
+	case *provider.ModelCallStartedResult:
+		return &result{Type: r.Type(), Model: r.GetModel()}
+	case *provider.ModelCallCompletedResult:
+		usage := r.GetUsage()
+		return &result{
+			Type:         r.Type(),
+			Model:        r.GetModel(),
+			ResponseID:   r.GetResponseID(),
+			FinishReason: r.GetFinishReason(),
+			Usage:        &usage,
+		}
+	}
+	return nil
+}
+
+// deltaStream is a delta result being replayed.
+type deltaStream interface {
+	provider.Result
+	AddDelta(text string)
+	Close()
+}
+
+// decode converts r back into a provider.Result. Delta results start with
+// the text they were yielded with; the rest arrives through delta events.
+func (r *result) decode() provider.Result {
+	switch r.Type {
+	case "message":
+		return provider.NewMessageResult(r.Text)
+	case "message_delta":
+		return provider.NewMessageDeltaResult(r.Text)
+	case "think":
+		return provider.NewReasoningResult(r.Text)
+	case "reasoning_delta_result":
+		return provider.NewReasoningDeltaResult(r.Text)
+	case "function_call":
+		return provider.NewFunctionCallResult(r.CallID, r.Name, r.Arguments)
+	case "function_call_output":
+		return provider.NewFunctionCallOutputResult(r.CallID, r.Name, r.Output)
+	case "model_call_started":
+		return provider.NewModelCallStartedResult(r.Model)
+	case "model_call_completed":
+		var usage provider.Usage
+		if r.Usage != nil {
+			usage = *r.Usage
+		}
+		return provider.NewModelCallCompletedResult(r.Model, r.ResponseID, r.FinishReason, usage)
+	}
+	return nil
+}
+
+// recorder records the results yielded by the wrapped provider, including
+// the deltas of streamed results, and passes them on.
+type recorder struct {
+	start time.Time
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	events  []event
+	results int
+}
+
+func newRecorder() *recorder {
+	return &recorder{start: time.Now()}
+}
+
+func (rec *recorder) add(e event) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	e.Offset = time.Since(rec.start)
+	rec.events = append(rec.events, e)
+}
+
+// record records r and returns the result to pass on. Delta results are
+// replaced by a copy that is fed from a goroutine, because their stream can
+// only be read once.
+func (rec *recorder) record(r provider.Result) provider.Result {
+	encoded := encodeResult(r)
+	if encoded == nil {
+		return r
+	}
+
+	rec.mu.Lock()
+	stream := rec.results
+	rec.results++
+	rec.mu.Unlock()
+	rec.add(event{Kind: eventResult, Result: encoded})
+
+	switch r := r.(type) {
+	case *provider.MessageDeltaResult:
+		out := provider.NewMessageDeltaResult(r.GetText())
+		rec.tee(stream, r.Subscribe(), out)
+		return out
+	case *provider.ReasoningDeltaResult:
+		out := provider.NewReasoningDeltaResult(r.GetText())
+		rec.tee(stream, r.Subscribe(), out)
+		return out
+	}
+	return r
+}
+
+// tee copies the deltas of a stream to out and records them. The first
+// delta is the text the result was yielded with, which is already part of
+// out and of the recorded result.
+func (rec *recorder) tee(stream int, in <-chan string, out deltaStream) {
+	rec.wg.Add(1)
+	go func() {
+		defer rec.wg.Done()
+		first := true
+		for delta := range in {
+			if first {
+				first = false
+				continue
+			}
+			rec.add(event{Kind: eventDelta, Stream: stream, Delta: delta})
+			out.AddDelta(delta)
+		}
+		out.Close()
+		rec.add(event{Kind: eventClose, Stream: stream})
+	}()
+}
+
+// finish waits for all streams to close and returns the recorded events.
+func (rec *recorder) finish() []event {
+	rec.wg.Wait()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.events
+}
+
+// replay yields recorded events. With pacing, it waits between events as
+// long as the original answer did.
+func replay(ctx context.Context, yield func(provider.Result) bool, events []event, pacing bool) error {
+	results := 0
+	open := map[int]deltaStream{}
+	defer func() {
+		for _, s := range open {
+			s.Close()
+		}
+	}()
+
+	start := time.Now()
+	for _, e := range events {
+		if pacing {
+			if wait := e.Offset - time.Since(start); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		switch e.Kind {
+		case eventResult:
+			r := e.Result.decode()
+			results++
+			if r == nil {
+				continue
+			}
+			if s, ok := r.(deltaStream); ok {
+				open[results-1] = s
+			}
+			if !yield(r) {
+				return errors.New("cancelled")
+			}
+		case eventDelta:
+			if s, ok := open[e.Stream]; ok {
+				s.AddDelta(e.Delta)
+			}
+		case eventClose:
+			if s, ok := open[e.Stream]; ok {
+				s.Close()
+				delete(open, e.Stream)
+			}
+		}
+	}
+	return nil
+}
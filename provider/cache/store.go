@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/internal/atomicfile"
+)
+
+// Store keeps encoded cache entries by key.
+type Store interface {
+	// Get returns the value stored under key. ok is false if there is none.
+	Get(key string) (value []byte, ok bool, err error)
+
+	// Set stores value under key, replacing any previous value.
+	Set(key string, value []byte) error
+}
+
+// MemoryStore is an in-memory Store that evicts the least recently used
+// entries. It is safe for concurrent use.
+type MemoryStore struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryStore creates a new MemoryStore holding at most maxEntries
+// entries. Zero means no limit.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*memoryItem).value, true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		e.Value.(*memoryItem).value = value
+		s.order.MoveToFront(e)
+		return nil
+	}
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, value: value})
+	if s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// DiskStore is a Store that keeps one file per entry in a directory, so that
+// the cache survives restarts.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a new DiskStore in dir. The directory is created when
+// the first entry is stored.
+func NewDiskStore(dir string) *DiskStore {
+	return &DiskStore{dir: dir}
+}
+
+// Get implements Store.
+func (s *DiskStore) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set implements Store. The file is replaced atomically.
+func (s *DiskStore) Set(key string, value []byte) error {
+	return atomicfile.Write(s.path(key), value)
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
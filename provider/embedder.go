@@ -0,0 +1,16 @@
+package provider
+
+import "context"
+
+// Embedder is implemented by providers that can turn texts into embedding
+// vectors, for example to build retrieval.
+//
+// Example usage:
+//
+//	embedder, ok := provider.(provider.Embedder)
+//	vectors, err := embedder.Embed(ctx, []string{"first document", "second document"})
+type Embedder interface {
+	// Embed returns one vector per text, in the same order. Large batches
+	// are split into several requests.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
@@ -0,0 +1,209 @@
+// Package fallback provides a provider.Provider that fails over to the next
+// provider in a list when a request fails, for example when one vendor is
+// rate limited or down.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// ErrorClass is a category of provider errors.
+type ErrorClass string
+
+const (
+	// ErrorClassRateLimit is an HTTP 429 response.
+	ErrorClassRateLimit ErrorClass = "rate_limit"
+	// ErrorClassServer is an HTTP 5xx response.
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassTimeout is a request timeout, either an HTTP 408 response or
+	// an expired deadline inside the SDK.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassNetwork is a connection failure.
+	ErrorClassNetwork ErrorClass = "network"
+	// ErrorClassAuth is an HTTP 401 or 403 response.
+	ErrorClassAuth ErrorClass = "auth"
+	// ErrorClassOther is any other error.
+	ErrorClassOther ErrorClass = "other"
+)
+
+// Classify returns the class of err.
+func Classify(err error) ErrorClass {
+	var apiErr *provider.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorClassRateLimit
+		case apiErr.StatusCode == http.StatusRequestTimeout:
+			return ErrorClassTimeout
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return ErrorClassAuth
+		case apiErr.StatusCode >= 500:
+			return ErrorClassServer
+		}
+		return ErrorClassOther
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
+
+// Provider tries a list of providers in order and moves to the next one when
+// a request fails with one of the configured error classes.
+//
+// The conversation is carried over between providers that implement
+// provider.HistoryProvider. When a provider fails, the next one receives the
+// history as it was before the failed question and answers the question
+// from the start, so tools may run again.
+type Provider struct {
+	providers []provider.Provider
+
+	// Index of the provider holding the latest conversation.
+	active int
+
+	shouldFallback func(error) bool
+}
+
+// ProviderOption configures a fallback Provider.
+type ProviderOption func(*Provider)
+
+// WithErrorClasses sets the error classes that trigger a fallback.
+// Default: ErrorClassRateLimit, ErrorClassServer, ErrorClassTimeout, ErrorClassNetwork
+func WithErrorClasses(classes ...ErrorClass) ProviderOption {
+	return func(p *Provider) {
+		set := map[ErrorClass]bool{}
+		for _, c := range classes {
+			set[c] = true
+		}
+		p.shouldFallback = func(err error) bool {
+			return set[Classify(err)]
+		}
+	}
+}
+
+// WithFallbackCondition sets a function deciding whether an error triggers a
+// fallback. It replaces WithErrorClasses.
+func WithFallbackCondition(shouldFallback func(error) bool) ProviderOption {
+	return func(p *Provider) {
+		p.shouldFallback = shouldFallback
+	}
+}
+
+// NewProvider creates a new fallback provider. Every question is first sent
+// to providers[0]; the others are only used when the ones before them fail.
+//
+// Example usage:
+//
+//	provider := fallback.NewProvider([]provider.Provider{
+//		openai.NewProvider(openaiClient),
+//		gemini.NewProvider(genaiClient),
+//	})
+func NewProvider(providers []provider.Provider, opts ...ProviderOption) provider.Provider {
+	p := &Provider{
+		providers: providers,
+	}
+	WithErrorClasses(ErrorClassRateLimit, ErrorClassServer, ErrorClassTimeout, ErrorClassNetwork)(p)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// ProcessMessage implements provider.Provider. A ProviderSwitchedResult is
+// yielded whenever the conversation moves to another provider. The history of
+// a provider that failed over is reset to where it was before the question.
+func (p *Provider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	if len(p.providers) == 0 {
+		return errors.New("fallback: no providers")
+	}
+
+	var history []provider.Message
+	if hp, ok := p.providers[p.active].(provider.HistoryProvider); ok {
+		history = hp.History()
+	}
+
+	var lastErr error
+	for i, prov := range p.providers {
+		if i != p.active {
+			if hp, ok := prov.(provider.HistoryProvider); ok {
+				hp.SetHistory(history)
+			}
+			from := provider.NameOf(p.providers[p.active])
+			p.active = i
+			if !yield(provider.NewProviderSwitchedResult(from, provider.NameOf(prov), lastErr)) {
+				return errors.New("cancelled")
+			}
+		}
+
+		lastErr = prov.ProcessMessage(ctx, yield, question)
+		if lastErr == nil || ctx.Err() != nil || !p.shouldFallback(lastErr) {
+			return lastErr
+		}
+		// Roll back the failed turn, which may end with function calls
+		// that were never answered.
+		if hp, ok := prov.(provider.HistoryProvider); ok {
+			hp.SetHistory(history)
+		}
+	}
+	return lastErr
+}
+
+// SetTools implements provider.Provider.
+func (p *Provider) SetTools(tools []provider.Tool) {
+	for _, prov := range p.providers {
+		prov.SetTools(tools)
+	}
+}
+
+// SetHooks implements provider.HooksProvider.
+func (p *Provider) SetHooks(hooks provider.Hooks) {
+	for _, prov := range p.providers {
+		if hp, ok := prov.(provider.HooksProvider); ok {
+			hp.SetHooks(hooks)
+		}
+	}
+}
+
+// Name implements provider.Namer. It returns the name of the provider that
+// answered last.
+func (p *Provider) Name() string {
+	if len(p.providers) == 0 {
+		return "fallback"
+	}
+	return provider.NameOf(p.providers[p.active])
+}
+
+// History implements provider.HistoryProvider.
+func (p *Provider) History() []provider.Message {
+	if len(p.providers) == 0 {
+		return nil
+	}
+	if hp, ok := p.providers[p.active].(provider.HistoryProvider); ok {
+		return hp.History()
+	}
+	return nil
+}
+
+// SetHistory implements provider.HistoryProvider.
+func (p *Provider) SetHistory(history []provider.Message) {
+	for _, prov := range p.providers {
+		if hp, ok := prov.(provider.HistoryProvider); ok {
+			hp.SetHistory(history)
+		}
+	}
+	p.active = 0
+}
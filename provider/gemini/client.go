@@ -3,7 +3,10 @@ package gemini
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/demouth/orenoagent-go/provider"
 	"google.golang.org/genai"
@@ -22,18 +25,57 @@ func (r Results) HasToolCallResult() bool {
 	return false
 }
 
+// Completed returns the ModelCallCompletedResult of the response, or nil.
+func (r Results) Completed() *provider.ModelCallCompletedResult {
+	for _, result := range r {
+		if completed, ok := result.(*provider.ModelCallCompletedResult); ok {
+			return completed
+		}
+	}
+	return nil
+}
+
+// defaultInstructions is the system prompt sent with every conversation.
+const defaultInstructions = `1. [MUST] Provide answers and reasoning in the language the user speaks to you in. Example: If asked in Japanese, respond in Japanese.
+2. [MUST] Never answer with speculation.`
+
 type client struct {
 	genaiClient *genai.Client
 	chat        *genai.Chat
 	tools       []provider.Tool
 
+	// System prompt
+	instructions string
+
 	// Model to use
 	model string
 
+	// Embedding model and vector size for Embed. Zero dimensions keeps the
+	// model's size.
+	embeddingModel      string
+	embeddingDimensions int
+
 	// Thinking configuration
 	thinkingBudget  *int32
 	includeThoughts bool
 
+	// Maximum number of tool rounds per message. Zero means no limit.
+	maxToolRounds int
+
+	// Retry policy for model rounds. Nil disables retries.
+	retryPolicy *provider.RetryPolicy
+
+	// Limiter shared with other providers. Nil means no limit.
+	limiter provider.Limiter
+
+	// Hooks around model and tool calls
+	hooks provider.Hooks
+
+	logger *slog.Logger
+
+	// History to start the next chat with, set by setHistory.
+	initialHistory []*genai.Content
+
 	latestMessageDeltaResult   *provider.MessageDeltaResult
 	latestReasoningDeltaResult *provider.ReasoningDeltaResult
 }
@@ -43,6 +85,9 @@ func newClient(genaiClient *genai.Client) *client {
 		genaiClient:     genaiClient,
 		tools:           []provider.Tool{},
 		model:           "gemini-2.5-flash-lite",
+		embeddingModel:  "gemini-embedding-001",
+		instructions:    defaultInstructions,
+		logger:          slog.New(slog.DiscardHandler),
 		includeThoughts: false,
 	}
 }
@@ -89,8 +134,7 @@ func (c *client) buildConfig() *genai.GenerateContentConfig {
 	config.SystemInstruction = &genai.Content{
 		Parts: []*genai.Part{
 			{
-				Text: `1. [MUST] Provide answers and reasoning in the language the user speaks to you in. Example: If asked in Japanese, respond in Japanese.
-2. [MUST] Never answer with speculation.`,
+				Text: c.instructions,
 			},
 		},
 	}
@@ -165,7 +209,7 @@ func (c *client) convertPropertyToSchema(prop map[string]any) *genai.Schema {
 func (c *client) ensureChat(ctx context.Context) error {
 	if c.chat == nil {
 		config := c.buildConfig()
-		chat, err := c.genaiClient.Chats.Create(ctx, c.model, config, nil)
+		chat, err := c.genaiClient.Chats.Create(ctx, c.model, config, c.initialHistory)
 		if err != nil {
 			return fmt.Errorf("failed to create chat: %w", err)
 		}
@@ -183,19 +227,18 @@ func (c *client) processMessageInput(
 		return err
 	}
 
-	respIter := c.chat.SendMessageStream(
-		ctx,
-		genai.Part{Text: question},
-	)
-
-	results, err := c.processResponseStream(ctx, yield, respIter)
+	results, err := c.processRound(ctx, yield, genai.Part{Text: question})
 	if err != nil {
 		return err
 	}
 
 	// Loop until no more function calls are needed
-	for results.HasToolCallResult() {
-		funcResults, err := c.executeFunctionCalls(results)
+	for rounds := 0; results.HasToolCallResult(); rounds++ {
+		if c.maxToolRounds > 0 && rounds >= c.maxToolRounds {
+			return provider.ErrToolLimit
+		}
+
+		funcResults, err := c.executeFunctionCalls(ctx, yield, results)
 		if err != nil {
 			return err
 		}
@@ -207,8 +250,7 @@ func (c *client) processMessageInput(
 			}
 		}
 
-		respIter = c.chat.SendMessageStream(ctx, parts...)
-		results, err = c.processResponseStream(ctx, yield, respIter)
+		results, err = c.processRound(ctx, yield, parts...)
 		if err != nil {
 			return err
 		}
@@ -217,7 +259,142 @@ func (c *client) processMessageInput(
 	return nil
 }
 
-func (c *client) executeFunctionCalls(results Results) ([]*genai.FunctionResponse, error) {
+// processRound sends parts to the chat and handles the streamed response,
+// retrying it according to the retry policy. The chat only records history
+// for successful responses, so a failed round can be sent again as is.
+func (c *client) processRound(
+	ctx context.Context,
+	yield func(provider.Result) bool,
+	parts ...genai.Part,
+) (Results, error) {
+	var results Results
+	call := func() error {
+		modelCall := provider.ModelCall{Model: c.model, Input: inputMessages(parts)}
+		return c.hooks.CallModel(ctx, modelCall, func(ctx context.Context) (*provider.ModelCallCompletedResult, error) {
+			var err error
+			results, err = c.streamRound(ctx, yield, parts...)
+			return results.Completed(), err
+		})
+	}
+	if c.retryPolicy == nil {
+		err := call()
+		return results, err
+	}
+	err := c.retryPolicy.Retry(ctx, yield, call)
+	return results, err
+}
+
+func (c *client) streamRound(
+	ctx context.Context,
+	yield func(provider.Result) bool,
+	parts ...genai.Part,
+) (Results, error) {
+	// usedTokens starts as the estimate and is replaced by the actual usage
+	// once the response reports it.
+	var usedTokens int
+	if c.limiter != nil {
+		usedTokens = c.estimateTokens(parts)
+		release, err := c.limiter.Acquire(ctx, usedTokens)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { release(usedTokens) }()
+	}
+
+	if !yield(provider.NewModelCallStartedResult(c.model)) {
+		return nil, fmt.Errorf("cancelled")
+	}
+
+	c.logger.DebugContext(ctx, "model request",
+		"model", c.model,
+		"tools", len(c.tools),
+		"parts", len(parts),
+		"history", len(c.chat.History(false)),
+		"include_thoughts", c.includeThoughts,
+	)
+	start := time.Now()
+	// Once output has been yielded, a failed round cannot be retried
+	// without repeating it.
+	var published bool
+	yieldOutput := func(r provider.Result) bool {
+		published = true
+		return yield(r)
+	}
+	respIter := c.chat.SendMessageStream(ctx, parts...)
+	results, err := c.processResponseStream(ctx, yieldOutput, respIter)
+	if completed := results.Completed(); completed != nil {
+		usedTokens = completed.GetUsage().TotalTokens
+	}
+	if err != nil {
+		c.closeDeltaResults()
+		c.logger.DebugContext(ctx, "model request failed", "model", c.model, "duration", time.Since(start), "error", err)
+		if published {
+			return nil, &provider.PartialOutputError{Err: toAPIError(err)}
+		}
+		return nil, toAPIError(err)
+	}
+	c.logger.DebugContext(ctx, "model response", "model", c.model, "duration", time.Since(start))
+	return results, nil
+}
+
+// estimateTokens estimates the tokens of a request sending parts, counting
+// the chat history as part of the prompt.
+func (c *client) estimateTokens(parts []genai.Part) int {
+	history, _ := json.Marshal(c.chat.History(false))
+	input, _ := json.Marshal(parts)
+	return provider.EstimateTokens(string(history) + string(input))
+}
+
+// closeDeltaResults closes delta results left open by an interrupted stream,
+// so that their subscribers do not wait forever.
+func (c *client) closeDeltaResults() {
+	if c.latestMessageDeltaResult != nil {
+		c.latestMessageDeltaResult.Close()
+		c.latestMessageDeltaResult = nil
+	}
+	if c.latestReasoningDeltaResult != nil {
+		c.latestReasoningDeltaResult.Close()
+		c.latestReasoningDeltaResult = nil
+	}
+}
+
+// toAPIError wraps a Gemini API error in a provider.APIError. The delay
+// requested by the server is read from the google.rpc.RetryInfo detail.
+func toAPIError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	var retryAfter time.Duration
+	for _, detail := range apiErr.Details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		if delay, ok := detail["retryDelay"].(string); ok {
+			retryAfter, _ = time.ParseDuration(delay)
+		}
+	}
+	return &provider.APIError{
+		StatusCode: apiErr.Code,
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+// callTool runs a tool requested by the model and logs the call.
+func (c *client) callTool(ctx context.Context, call provider.ToolCall) string {
+	if _, ok := provider.FindTool(c.tools, call.Name); !ok {
+		c.logger.WarnContext(ctx, "unknown tool", "tool", call.Name)
+	}
+	c.logger.DebugContext(ctx, "tool call", "tool", call.Name, "arguments", call.Arguments)
+	start := time.Now()
+	output := c.hooks.CallTool(ctx, c.tools, call)
+	c.logger.DebugContext(ctx, "tool call done", "tool", call.Name,
+		"duration", time.Since(start), "output_bytes", len(output))
+	return output
+}
+
+func (c *client) executeFunctionCalls(ctx context.Context, yield func(provider.Result) bool, results Results) ([]*genai.FunctionResponse, error) {
 	var funcResponses []*genai.FunctionResponse
 
 	for _, result := range results {
@@ -228,23 +405,22 @@ func (c *client) executeFunctionCalls(results Results) ([]*genai.FunctionRespons
 		fcResult := result.(*provider.FunctionCallResult)
 
 		// Find and execute the tool
-		var callResult string
-		for _, t := range c.tools {
-			if fcResult.GetName() == t.Name {
-				callResult = t.Function(fcResult.GetArguments())
-				break
-			}
+		callResult := c.callTool(ctx, provider.ToolCall{
+			CallID:    fcResult.GetCallID(),
+			Name:      fcResult.GetName(),
+			Arguments: fcResult.GetArguments(),
+		})
+		if !yield(provider.NewFunctionCallOutputResult(fcResult.GetCallID(), fcResult.GetName(), callResult)) {
+			return nil, errors.New("cancel iter")
 		}
 
 		// Parse the result as JSON if possible, otherwise use as string
-		var response map[string]any
-		if err := json.Unmarshal([]byte(callResult), &response); err != nil {
-			response = map[string]any{"result": callResult}
+		if !json.Valid([]byte(callResult)) {
+			c.logger.DebugContext(ctx, "tool output is not JSON, sending it as text", "tool", fcResult.GetName())
 		}
-
 		funcResponses = append(funcResponses, &genai.FunctionResponse{
 			Name:     fcResult.GetName(),
-			Response: response,
+			Response: functionResponse(callResult),
 		})
 	}
 
@@ -252,13 +428,19 @@ func (c *client) executeFunctionCalls(results Results) ([]*genai.FunctionRespons
 }
 
 func (c *client) processResponseStream(
-	_ context.Context,
+	ctx context.Context,
 	yield func(provider.Result) bool,
 	respIter func(func(*genai.GenerateContentResponse, error) bool),
 ) (Results, error) {
 	var results Results
 	var inThought bool
 	var inMessage bool
+	modelVersion := c.model
+	var responseID string
+	var finishReason genai.FinishReason
+	var blocked bool
+	var usage provider.Usage
+	callCount := countFunctionCalls(c.chat.History(true))
 
 	for resp, err := range respIter {
 		if err != nil {
@@ -267,8 +449,32 @@ func (c *client) processResponseStream(
 		if resp == nil {
 			continue
 		}
+		c.logger.DebugContext(ctx, "stream chunk", "candidates", len(resp.Candidates), "response_id", resp.ResponseID)
+
+		if resp.ModelVersion != "" {
+			modelVersion = resp.ModelVersion
+		}
+		if resp.ResponseID != "" {
+			responseID = resp.ResponseID
+		}
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			blocked = true
+		}
+		if m := resp.UsageMetadata; m != nil {
+			// Candidate tokens do not include thoughts.
+			usage = provider.Usage{
+				InputTokens:     int(m.PromptTokenCount + m.ToolUsePromptTokenCount),
+				OutputTokens:    int(m.CandidatesTokenCount + m.ThoughtsTokenCount),
+				TotalTokens:     int(m.TotalTokenCount),
+				CachedTokens:    int(m.CachedContentTokenCount),
+				ReasoningTokens: int(m.ThoughtsTokenCount),
+			}
+		}
 
 		for _, candidate := range resp.Candidates {
+			if candidate.FinishReason != "" {
+				finishReason = candidate.FinishReason
+			}
 			if candidate.Content == nil {
 				continue
 			}
@@ -290,11 +496,15 @@ func (c *client) processResponseStream(
 
 					argsJSON, err := json.Marshal(p.FunctionCall.Args)
 					if err != nil {
+						c.logger.WarnContext(ctx, "cannot encode function call arguments",
+							"tool", p.FunctionCall.Name, "error", err)
 						argsJSON = []byte("{}")
 					}
 
-					// Gemini doesn't have callID, so we pass empty string
-					result := provider.NewFunctionCallResult("", p.FunctionCall.Name, string(argsJSON))
+					// Missing IDs are numbered like in getHistory, so that the
+					// results match the messages of the history.
+					callCount++
+					result := provider.NewFunctionCallResult(callID(p.FunctionCall, callCount), p.FunctionCall.Name, string(argsJSON))
 					if !yield(result) {
 						return nil, fmt.Errorf("cancelled")
 					}
@@ -381,5 +591,52 @@ func (c *client) processResponseStream(
 		results = append(results, reasoningResult)
 	}
 
+	reason := mapFinishReason(finishReason, results.HasToolCallResult())
+	if blocked {
+		reason = provider.FinishReasonContentFilter
+	}
+	completed := provider.NewModelCallCompletedResult(modelVersion, responseID, reason, usage)
+	if !yield(completed) {
+		return nil, fmt.Errorf("cancelled")
+	}
+	results = append(results, completed)
+
 	return results, nil
 }
+
+// mapFinishReason maps a Gemini FinishReason to a provider.FinishReason.
+func mapFinishReason(reason genai.FinishReason, hasToolCall bool) provider.FinishReason {
+	switch reason {
+	case genai.FinishReasonStop, "":
+		if hasToolCall {
+			return provider.FinishReasonToolCalls
+		}
+		return provider.FinishReasonStop
+	case genai.FinishReasonMaxTokens:
+		return provider.FinishReasonMaxTokens
+	case genai.FinishReasonSafety,
+		genai.FinishReasonRecitation,
+		genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent,
+		genai.FinishReasonSPII,
+		genai.FinishReasonImageSafety,
+		genai.FinishReasonImageProhibitedContent,
+		genai.FinishReasonImageRecitation:
+		return provider.FinishReasonContentFilter
+	default:
+		return provider.FinishReasonOther
+	}
+}
+
+func (c *client) getHistory() []provider.Message {
+	if c.chat == nil {
+		return toMessages(c.initialHistory)
+	}
+	return toMessages(c.chat.History(true))
+}
+
+// setHistory replaces the history. A new chat is created for the next message.
+func (c *client) setHistory(history []provider.Message) {
+	c.initialHistory = toContents(history)
+	c.chat = nil
+}
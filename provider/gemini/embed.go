@@ -0,0 +1,85 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"google.golang.org/genai"
+)
+
+// maxEmbeddingInputs is the largest number of texts the API embeds in one
+// request.
+const maxEmbeddingInputs = 100
+
+// embed returns the embeddings of texts, sending one request per batch.
+func (c *client) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingInputs {
+		batch := texts[start:min(start+maxEmbeddingInputs, len(texts))]
+		var batchVectors [][]float32
+		call := func() error {
+			var err error
+			batchVectors, err = c.embedBatch(ctx, batch)
+			return err
+		}
+		var err error
+		if c.retryPolicy == nil {
+			err = call()
+		} else {
+			// Retries are not reported, as Embed has no results to yield.
+			err = c.retryPolicy.Retry(ctx, func(provider.Result) bool { return true }, call)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batchVectors...)
+	}
+	return vectors, nil
+}
+
+func (c *client) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	// The API does not report token usage for embeddings, so the estimate
+	// is kept.
+	if c.limiter != nil {
+		var tokens int
+		for _, text := range texts {
+			tokens += provider.EstimateTokens(text)
+		}
+		release, err := c.limiter.Acquire(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { release(tokens) }()
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+	config := &genai.EmbedContentConfig{}
+	if c.embeddingDimensions > 0 {
+		config.OutputDimensionality = genai.Ptr(int32(c.embeddingDimensions))
+	}
+
+	start := time.Now()
+	resp, err := c.genaiClient.Models.EmbedContent(ctx, c.embeddingModel, contents, config)
+	if err != nil {
+		c.logger.WarnContext(ctx, "embedding request failed", "model", c.embeddingModel, "error", err)
+		return nil, toAPIError(err)
+	}
+	c.logger.DebugContext(ctx, "embedding request done", "model", c.embeddingModel,
+		"texts", len(texts), "duration", time.Since(start))
+
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini: got %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		if e != nil {
+			vectors[i] = e.Values
+		}
+	}
+	return vectors, nil
+}
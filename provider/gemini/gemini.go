@@ -2,6 +2,7 @@ package gemini
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/demouth/orenoagent-go/provider"
 	"google.golang.org/genai"
@@ -39,6 +40,76 @@ func WithIncludeThoughts(include bool) ProviderOption {
 	}
 }
 
+// WithMaxToolRounds limits how many rounds of tool calls are executed for a
+// single message. When the limit is reached, ProcessMessage returns
+// provider.ErrToolLimit. Zero (the default) means no limit.
+func WithMaxToolRounds(rounds int) ProviderOption {
+	return func(p *Provider) {
+		p.client.maxToolRounds = rounds
+	}
+}
+
+// WithRetryPolicy retries a model round that fails with a transient error,
+// such as a rate limit or a server error. Only the failed request is sent
+// again; tools that already ran are not executed twice. A RetryResult is
+// yielded before each retry. A stream that fails after yielding output is not
+// retried, so that the output is not repeated.
+//
+// Example usage:
+//
+//	WithRetryPolicy(provider.DefaultRetryPolicy())
+func WithRetryPolicy(policy provider.RetryPolicy) ProviderOption {
+	return func(p *Provider) {
+		p.client.retryPolicy = &policy
+	}
+}
+
+// WithLimiter makes every request to the model wait for the limiter first.
+// Pass the same limiter to several providers to share one budget between
+// them.
+//
+// Example usage:
+//
+//	WithLimiter(ratelimit.NewLimiter(ratelimit.WithRequestsPerMinute(500)))
+func WithLimiter(limiter provider.Limiter) ProviderOption {
+	return func(p *Provider) {
+		p.client.limiter = limiter
+	}
+}
+
+// WithInstructions sets the system prompt.
+// Default: answer in the user's language and never speculate
+func WithInstructions(instructions string) ProviderOption {
+	return func(p *Provider) {
+		p.client.instructions = instructions
+	}
+}
+
+// WithEmbeddingModel sets the model used by Embed.
+// Default: "gemini-embedding-001"
+func WithEmbeddingModel(model string) ProviderOption {
+	return func(p *Provider) {
+		p.client.embeddingModel = model
+	}
+}
+
+// WithEmbeddingDimensions shortens the vectors returned by Embed, if the
+// embedding model supports it. Zero (the default) keeps the model's size.
+func WithEmbeddingDimensions(dimensions int) ProviderOption {
+	return func(p *Provider) {
+		p.client.embeddingDimensions = dimensions
+	}
+}
+
+// WithLogger sets the logger. Requests, stream events and tool calls are
+// logged at debug level, and anomalies such as unknown tools at warn level.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) ProviderOption {
+	return func(p *Provider) {
+		p.client.logger = logger
+	}
+}
+
 // NewProvider creates a new Gemini provider.
 //
 // Example usage:
@@ -62,7 +133,37 @@ func (p *Provider) ProcessMessage(ctx context.Context, yield func(provider.Resul
 	return p.client.processMessageInput(ctx, yield, question)
 }
 
+// Name implements provider.Namer.
+func (p *Provider) Name() string {
+	return "gemini:" + p.client.model
+}
+
+// Instructions implements provider.InstructionsProvider.
+func (p *Provider) Instructions() string {
+	return p.client.instructions
+}
+
+// Embed implements provider.Embedder.
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return p.client.embed(ctx, texts)
+}
+
 // SetTools implements provider.Provider.
 func (p *Provider) SetTools(tools []provider.Tool) {
 	p.client.tools = tools
 }
+
+// SetHooks implements provider.HooksProvider.
+func (p *Provider) SetHooks(hooks provider.Hooks) {
+	p.client.hooks = hooks
+}
+
+// History implements provider.HistoryProvider.
+func (p *Provider) History() []provider.Message {
+	return p.client.getHistory()
+}
+
+// SetHistory implements provider.HistoryProvider.
+func (p *Provider) SetHistory(history []provider.Message) {
+	p.client.setHistory(history)
+}
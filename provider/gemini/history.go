@@ -0,0 +1,171 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"google.golang.org/genai"
+)
+
+// toMessages converts chat history into vendor-neutral messages. Gemini does
+// not always assign IDs to function calls, so missing IDs are generated and
+// the responses that follow are matched to their calls in order.
+func toMessages(contents []*genai.Content) []provider.Message {
+	var messages []provider.Message
+	var pendingIDs []string
+	callCount := 0
+
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		var text string
+		var calls []provider.FunctionCall
+		for _, p := range content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				callCount++
+				id := callID(p.FunctionCall, callCount)
+				args, err := json.Marshal(p.FunctionCall.Args)
+				if err != nil {
+					args = []byte("{}")
+				}
+				calls = append(calls, provider.FunctionCall{
+					CallID:    id,
+					Name:      p.FunctionCall.Name,
+					Arguments: string(args),
+				})
+				pendingIDs = append(pendingIDs, id)
+			case p.FunctionResponse != nil:
+				id := p.FunctionResponse.ID
+				if len(pendingIDs) > 0 {
+					if id == "" {
+						id = pendingIDs[0]
+					}
+					pendingIDs = pendingIDs[1:]
+				}
+				messages = append(messages, provider.Message{
+					Role:   provider.RoleTool,
+					Text:   functionResponseText(p.FunctionResponse.Response),
+					CallID: id,
+					Name:   p.FunctionResponse.Name,
+				})
+			case p.Text != "" && !p.Thought:
+				text += p.Text
+			}
+		}
+
+		if text == "" && len(calls) == 0 {
+			continue
+		}
+		role := provider.RoleUser
+		if content.Role == genai.RoleModel {
+			role = provider.RoleAssistant
+		}
+		messages = append(messages, provider.Message{
+			Role:          role,
+			Text:          text,
+			FunctionCalls: calls,
+		})
+	}
+	return messages
+}
+
+// callID returns the ID of fc, the n-th function call of the history,
+// generating one when Gemini did not assign it.
+func callID(fc *genai.FunctionCall, n int) string {
+	if fc.ID != "" {
+		return fc.ID
+	}
+	return fmt.Sprintf("call_%d", n)
+}
+
+// countFunctionCalls returns the number of function calls in contents.
+func countFunctionCalls(contents []*genai.Content) int {
+	n := 0
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		for _, p := range content.Parts {
+			if p.FunctionCall != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// inputMessages converts the parts of a request into messages.
+func inputMessages(parts []genai.Part) []provider.Message {
+	content := &genai.Content{Role: genai.RoleUser}
+	for i := range parts {
+		content.Parts = append(content.Parts, &parts[i])
+	}
+	return toMessages([]*genai.Content{content})
+}
+
+// toContents converts vendor-neutral messages into chat history.
+func toContents(messages []provider.Message) []*genai.Content {
+	var contents []*genai.Content
+	for _, m := range messages {
+		switch m.Role {
+		case provider.RoleUser:
+			contents = append(contents, genai.NewContentFromText(m.Text, genai.RoleUser))
+		case provider.RoleAssistant:
+			var parts []*genai.Part
+			if m.Text != "" {
+				parts = append(parts, genai.NewPartFromText(m.Text))
+			}
+			for _, fc := range m.FunctionCalls {
+				var args map[string]any
+				if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+					args = map[string]any{}
+				}
+				parts = append(parts, genai.NewPartFromFunctionCall(fc.Name, args))
+			}
+			contents = append(contents, genai.NewContentFromParts(parts, genai.RoleModel))
+		case provider.RoleTool:
+			part := genai.NewPartFromFunctionResponse(m.Name, functionResponse(m.Text))
+			// Function responses answering the same model turn share one content.
+			if n := len(contents); n > 0 && contents[n-1].Role == genai.RoleUser && isFunctionResponses(contents[n-1]) {
+				contents[n-1].Parts = append(contents[n-1].Parts, part)
+				continue
+			}
+			contents = append(contents, genai.NewContentFromParts([]*genai.Part{part}, genai.RoleUser))
+		}
+	}
+	return contents
+}
+
+func isFunctionResponses(content *genai.Content) bool {
+	for _, p := range content.Parts {
+		if p.FunctionResponse == nil {
+			return false
+		}
+	}
+	return len(content.Parts) > 0
+}
+
+// functionResponse parses a tool output as JSON if possible, otherwise wraps
+// it as a string.
+func functionResponse(output string) map[string]any {
+	var response map[string]any
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		response = map[string]any{"result": output}
+	}
+	return response
+}
+
+// functionResponseText is the inverse of functionResponse.
+func functionResponseText(response map[string]any) string {
+	if result, ok := response["result"].(string); ok && len(response) == 1 {
+		return result
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
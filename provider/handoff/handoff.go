@@ -0,0 +1,256 @@
+// Package handoff provides a provider.Provider in which specialized agents
+// hand the conversation to each other, for example a triage agent that
+// transfers billing questions to a billing agent.
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Agent is a participant of the conversation with its own provider. Give the
+// provider its own system prompt, for example with openai.WithInstructions.
+type Agent struct {
+	// Name identifies the agent in transfer tools and results.
+	Name string
+
+	// Description tells the other agents when to transfer to this agent.
+	Description string
+
+	// Provider answers while the agent holds the conversation. It should
+	// implement provider.HistoryProvider so that the conversation can be
+	// moved to it.
+	Provider provider.Provider
+
+	// Tools are available only to this agent.
+	Tools []provider.Tool
+
+	// Handoffs names the agents this agent may transfer to. Nil allows all
+	// other agents.
+	Handoffs []string
+}
+
+// Provider lets the model of the active agent transfer the conversation to
+// another agent by calling a "transfer_to_<name>" tool. The target agent
+// then receives the conversation history and answers the question itself.
+type Provider struct {
+	agents      []Agent
+	tools       []provider.Tool
+	maxHandoffs int
+
+	// Index of the agent holding the conversation.
+	active int
+}
+
+// ProviderOption configures a Provider.
+type ProviderOption func(*Provider)
+
+// WithMaxHandoffs limits how many times the conversation may be transferred
+// while answering one question, to stop agents from passing it back and
+// forth forever.
+// Default: 5
+func WithMaxHandoffs(n int) ProviderOption {
+	return func(p *Provider) {
+		p.maxHandoffs = n
+	}
+}
+
+// NewProvider creates a new handoff provider. The first agent starts the
+// conversation.
+//
+// Example usage:
+//
+//	provider := handoff.NewProvider([]handoff.Agent{
+//		{Name: "triage", Provider: openai.NewProvider(client, openai.WithInstructions(triagePrompt))},
+//		{Name: "billing", Description: "Invoices, payments and refunds.", Provider: openai.NewProvider(client, openai.WithInstructions(billingPrompt)), Tools: billingTools},
+//		{Name: "support", Description: "Technical problems.", Provider: openai.NewProvider(client, openai.WithInstructions(supportPrompt)), Tools: supportTools},
+//	})
+func NewProvider(agents []Agent, opts ...ProviderOption) provider.Provider {
+	p := &Provider{
+		agents:      agents,
+		maxHandoffs: 5,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.updateTools()
+	return p
+}
+
+// ProcessMessage implements provider.Provider. When the active agent calls a
+// transfer tool, its run is stopped, a HandoffResult is yielded and the
+// target agent answers the question with the history from before it.
+func (p *Provider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	if len(p.agents) == 0 {
+		return errors.New("handoff: no agents")
+	}
+
+	// The partial answer of an agent that hands off is not kept; the target
+	// continues from the conversation before the question.
+	var history []provider.Message
+	if hp, ok := p.agents[p.active].Provider.(provider.HistoryProvider); ok {
+		history = hp.History()
+	}
+
+	for handoffs := 0; ; handoffs++ {
+		t := &transfer{}
+		var transferred bool
+		err := p.agents[p.active].Provider.ProcessMessage(context.WithValue(ctx, transferKey{}, t), func(r provider.Result) bool {
+			if !yield(r) {
+				return false
+			}
+			// Stop the run as soon as the transfer tool has answered.
+			if out, ok := r.(*provider.FunctionCallOutputResult); ok && t.to != "" && out.GetName() == transferToolName(t.to) {
+				transferred = true
+				return false
+			}
+			return true
+		}, question)
+		if !transferred {
+			return err
+		}
+		if handoffs >= p.maxHandoffs {
+			return fmt.Errorf("handoff: more than %d handoffs for one question", p.maxHandoffs)
+		}
+
+		from := p.agents[p.active]
+		p.active = p.indexOf(t.to)
+		to := p.agents[p.active]
+		if hp, ok := to.Provider.(provider.HistoryProvider); ok {
+			hp.SetHistory(history)
+		}
+		if !yield(provider.NewHandoffResult(from.Name, to.Name, t.reason)) {
+			return errors.New("cancelled")
+		}
+	}
+}
+
+// transfer records the transfer requested during one run.
+type transfer struct {
+	to     string
+	reason string
+}
+
+type transferKey struct{}
+
+func transferToolName(agent string) string {
+	return "transfer_to_" + agent
+}
+
+// transferTool returns the tool that transfers the conversation to target.
+func transferTool(target Agent) provider.Tool {
+	description := fmt.Sprintf("Transfer the conversation to the %s agent.", target.Name)
+	if target.Description != "" {
+		description += " " + target.Description
+	}
+	return provider.Tool{
+		Name:        transferToolName(target.Name),
+		Description: description,
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"reason": map[string]any{
+					"type":        "string",
+					"description": "Why the conversation is transferred.",
+				},
+			},
+		},
+		FunctionContext: func(ctx context.Context, arguments string) string {
+			t, ok := ctx.Value(transferKey{}).(*transfer)
+			if !ok {
+				return "error: transfers are only possible inside a handoff provider"
+			}
+			if t.to == "" {
+				var args struct {
+					Reason string `json:"reason"`
+				}
+				json.Unmarshal([]byte(arguments), &args)
+				t.to = target.Name
+				t.reason = args.Reason
+			}
+			return fmt.Sprintf("Transferred to the %s agent.", target.Name)
+		},
+	}
+}
+
+// updateTools gives every agent its own tools, the shared tools and its
+// transfer tools.
+func (p *Provider) updateTools() {
+	for _, a := range p.agents {
+		tools := slices.Concat(a.Tools, p.tools)
+		for _, target := range p.agents {
+			if target.Name == a.Name {
+				continue
+			}
+			if a.Handoffs != nil && !slices.Contains(a.Handoffs, target.Name) {
+				continue
+			}
+			tools = append(tools, transferTool(target))
+		}
+		a.Provider.SetTools(tools)
+	}
+}
+
+func (p *Provider) indexOf(name string) int {
+	for i, a := range p.agents {
+		if a.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetTools implements provider.Provider. The tools are shared by all agents,
+// in addition to their own.
+func (p *Provider) SetTools(tools []provider.Tool) {
+	p.tools = tools
+	p.updateTools()
+}
+
+// SetHooks implements provider.HooksProvider.
+func (p *Provider) SetHooks(hooks provider.Hooks) {
+	for _, a := range p.agents {
+		if hp, ok := a.Provider.(provider.HooksProvider); ok {
+			hp.SetHooks(hooks)
+		}
+	}
+}
+
+// Name implements provider.Namer. It returns the name of the active agent's
+// provider.
+func (p *Provider) Name() string {
+	if len(p.agents) == 0 {
+		return "handoff"
+	}
+	return provider.NameOf(p.agents[p.active].Provider)
+}
+
+// History implements provider.HistoryProvider.
+func (p *Provider) History() []provider.Message {
+	if len(p.agents) == 0 {
+		return nil
+	}
+	if hp, ok := p.agents[p.active].Provider.(provider.HistoryProvider); ok {
+		return hp.History()
+	}
+	return nil
+}
+
+// SetHistory implements provider.HistoryProvider. The first agent takes over
+// the given conversation.
+func (p *Provider) SetHistory(history []provider.Message) {
+	if len(p.agents) == 0 {
+		return
+	}
+	p.active = 0
+	if hp, ok := p.agents[0].Provider.(provider.HistoryProvider); ok {
+		hp.SetHistory(history)
+	}
+}
@@ -0,0 +1,168 @@
+package handoff_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"github.com/demouth/orenoagent-go/provider/handoff"
+)
+
+// fakeAgent either calls the transfer tool to transferTo or answers. Like
+// the real providers, it records the question in its history before
+// calling tools.
+type fakeAgent struct {
+	name       string
+	transferTo string
+	tools      []provider.Tool
+	history    []provider.Message
+
+	// seen is the history at the start of each question.
+	seen [][]provider.Message
+}
+
+func (a *fakeAgent) Name() string                          { return "fake:" + a.name }
+func (a *fakeAgent) SetTools(tools []provider.Tool)        { a.tools = tools }
+func (a *fakeAgent) History() []provider.Message           { return slices.Clone(a.history) }
+func (a *fakeAgent) SetHistory(history []provider.Message) { a.history = slices.Clone(history) }
+
+func (a *fakeAgent) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	a.seen = append(a.seen, a.History())
+	a.history = append(a.history, provider.Message{Role: provider.RoleUser, Text: question})
+
+	if a.transferTo != "" {
+		tool, ok := provider.FindTool(a.tools, "transfer_to_"+a.transferTo)
+		if !ok {
+			return fmt.Errorf("%s has no transfer tool to %s", a.name, a.transferTo)
+		}
+		args := `{"reason":"` + a.name + ` cannot help"}`
+		if !yield(provider.NewFunctionCallResult("call_1", tool.Name, args)) {
+			return errors.New("cancelled")
+		}
+		output := provider.Hooks{}.CallTool(ctx, a.tools, provider.ToolCall{CallID: "call_1", Name: tool.Name, Arguments: args})
+		if !yield(provider.NewFunctionCallOutputResult("call_1", tool.Name, output)) {
+			return errors.New("cancelled")
+		}
+		return errors.New("the run should have been stopped")
+	}
+
+	answer := a.name + " answers " + question
+	a.history = append(a.history, provider.Message{Role: provider.RoleAssistant, Text: answer})
+	yield(provider.NewMessageResult(answer))
+	return nil
+}
+
+// ask returns the results of a question in a comparable form.
+func ask(p provider.Provider, question string) ([]string, error) {
+	var got []string
+	err := p.ProcessMessage(context.Background(), func(r provider.Result) bool {
+		switch r := r.(type) {
+		case *provider.FunctionCallOutputResult:
+			got = append(got, "output "+r.GetName()+": "+r.GetOutput())
+		case *provider.HandoffResult:
+			got = append(got, fmt.Sprintf("handoff %s -> %s: %s", r.GetFrom(), r.GetTo(), r.GetReason()))
+		case *provider.MessageResult:
+			got = append(got, "message: "+r.GetText())
+		default:
+			got = append(got, r.Type())
+		}
+		return true
+	}, question)
+	return got, err
+}
+
+func TestHandoff(t *testing.T) {
+	triage := &fakeAgent{name: "triage", transferTo: "billing"}
+	billing := &fakeAgent{name: "billing"}
+	support := &fakeAgent{name: "support"}
+	p := handoff.NewProvider([]handoff.Agent{
+		{Name: "triage", Provider: triage, Handoffs: []string{"billing"}},
+		{Name: "billing", Description: "Invoices.", Provider: billing},
+		{Name: "support", Provider: support},
+	})
+
+	earlier := []provider.Message{
+		{Role: provider.RoleUser, Text: "Hi"},
+		{Role: provider.RoleAssistant, Text: "Hello"},
+	}
+	p.(provider.HistoryProvider).SetHistory(earlier)
+
+	got, err := ask(p, "Refund?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"function_call",
+		"output transfer_to_billing: Transferred to the billing agent.",
+		"handoff triage -> billing: triage cannot help",
+		"message: billing answers Refund?",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// billing continues from the conversation before the question, without
+	// triage's partial run.
+	if len(billing.seen) != 1 || fmt.Sprint(billing.seen[0]) != fmt.Sprint(earlier) {
+		t.Errorf("billing saw history %v, want %v", billing.seen, earlier)
+	}
+	if name := provider.NameOf(p); name != "fake:billing" {
+		t.Errorf("Name = %s, want the active agent fake:billing", name)
+	}
+
+	// Handoffs limits the transfer tools.
+	var names []string
+	for _, tool := range triage.tools {
+		names = append(names, tool.Name)
+	}
+	if !slices.Equal(names, []string{"transfer_to_billing"}) {
+		t.Errorf("triage tools = %v, want [transfer_to_billing]", names)
+	}
+	if _, ok := provider.FindTool(billing.tools, "transfer_to_support"); !ok {
+		t.Error("billing cannot transfer to support")
+	}
+
+	// The next question goes to billing directly.
+	if got, err := ask(p, "Thanks"); err != nil || !slices.Equal(got, []string{"message: billing answers Thanks"}) {
+		t.Errorf("next question = %v, %v", got, err)
+	}
+}
+
+func TestHandoffLimit(t *testing.T) {
+	ping := &fakeAgent{name: "ping", transferTo: "pong"}
+	pong := &fakeAgent{name: "pong", transferTo: "ping"}
+	p := handoff.NewProvider([]handoff.Agent{
+		{Name: "ping", Provider: ping},
+		{Name: "pong", Provider: pong},
+	}, handoff.WithMaxHandoffs(2))
+
+	got, err := ask(p, "Who answers?")
+	if err == nil || !strings.Contains(err.Error(), "more than 2 handoffs") {
+		t.Errorf("err = %v, want the handoff limit", err)
+	}
+	var handoffs int
+	for _, r := range got {
+		if strings.HasPrefix(r, "handoff ") {
+			handoffs++
+		}
+	}
+	if handoffs != 2 {
+		t.Errorf("%d handoffs, want 2", handoffs)
+	}
+}
+
+func TestTransferOutsideHandoff(t *testing.T) {
+	agent := &fakeAgent{name: "a"}
+	handoff.NewProvider([]handoff.Agent{
+		{Name: "a", Provider: agent},
+		{Name: "b", Provider: &fakeAgent{name: "b"}},
+	})
+	tool, _ := provider.FindTool(agent.tools, "transfer_to_b")
+	if output := tool.FunctionContext(context.Background(), `{}`); !strings.HasPrefix(output, "error: ") {
+		t.Errorf("output = %q, want an error", output)
+	}
+}
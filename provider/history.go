@@ -0,0 +1,50 @@
+package provider
+
+// Role is the author of a Message.
+type Role string
+
+const (
+	// RoleUser marks a question from the user.
+	RoleUser Role = "user"
+	// RoleAssistant marks text or function calls from the model.
+	RoleAssistant Role = "assistant"
+	// RoleTool marks the output of a function call.
+	RoleTool Role = "tool"
+)
+
+// Message is one entry of a conversation in a vendor-neutral form, so that a
+// conversation can be moved from one provider to another.
+type Message struct {
+	Role Role
+
+	// Text is the message text for RoleUser and RoleAssistant, and the
+	// function output for RoleTool.
+	Text string
+
+	// FunctionCalls are the calls requested by the model (RoleAssistant only).
+	FunctionCalls []FunctionCall
+
+	// CallID and Name identify the call answered by a RoleTool message.
+	CallID string
+	Name   string
+}
+
+// FunctionCall is a function call requested by the model.
+type FunctionCall struct {
+	CallID    string
+	Name      string
+	Arguments string
+}
+
+// HistoryProvider is implemented by providers whose conversation history can
+// be exported and replaced.
+type HistoryProvider interface {
+	Provider
+
+	// History returns the conversation so far.
+	History() []Message
+
+	// SetHistory replaces the conversation. The next message is sent with
+	// the given history as context.
+	SetHistory(history []Message)
+}
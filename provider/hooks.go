@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+)
+
+// ModelCall describes a request to the model.
+type ModelCall struct {
+	// Model is the requested model name.
+	Model string
+
+	// Input holds the messages sent in this request: the question, or the
+	// outputs of the previous round's tool calls. Earlier messages are not
+	// included.
+	Input []Message
+}
+
+// ToolCall describes a call of a tool requested by the model.
+type ToolCall struct {
+	CallID    string
+	Name      string
+	Arguments string
+}
+
+// Hooks are called around model calls and tool calls. Any field may be nil.
+type Hooks struct {
+	// BeforeModelCall is called before each request to the model, including
+	// retries. The returned context is used for the request and passed to
+	// AfterModelCall, so it may carry values such as a tracing span. An
+	// error aborts the message.
+	BeforeModelCall func(ctx context.Context, call ModelCall) (context.Context, error)
+
+	// AfterModelCall is called when a request has finished, even if it was
+	// aborted by BeforeModelCall. completed is nil if the request failed.
+	AfterModelCall func(ctx context.Context, call ModelCall, completed *ModelCallCompletedResult, err error)
+
+	// BeforeToolCall is called before a tool runs. It may change
+	// call.Arguments. The returned context is passed to AfterToolCall. An
+	// error vetoes the call; the model then receives the error message as
+	// the tool's output.
+	BeforeToolCall func(ctx context.Context, call *ToolCall) (context.Context, error)
+
+	// AfterToolCall is called after a tool has run or was vetoed, and
+	// returns the output sent to the model, so it may rewrite the output.
+	AfterToolCall func(ctx context.Context, call ToolCall, output string) string
+}
+
+// HooksProvider is implemented by providers that call Hooks.
+type HooksProvider interface {
+	Provider
+
+	// SetHooks replaces the hooks.
+	SetHooks(hooks Hooks)
+}
+
+// ChainHooks combines several Hooks into one. The hooks run in the given
+// order. The first error from a Before hook stops the chain; After hooks
+// always run.
+func ChainHooks(hooks ...Hooks) Hooks {
+	return Hooks{
+		BeforeModelCall: func(ctx context.Context, call ModelCall) (context.Context, error) {
+			for _, h := range hooks {
+				if h.BeforeModelCall == nil {
+					continue
+				}
+				next, err := h.BeforeModelCall(ctx, call)
+				if next != nil {
+					ctx = next
+				}
+				if err != nil {
+					return ctx, err
+				}
+			}
+			return ctx, nil
+		},
+		AfterModelCall: func(ctx context.Context, call ModelCall, completed *ModelCallCompletedResult, err error) {
+			for _, h := range hooks {
+				if h.AfterModelCall != nil {
+					h.AfterModelCall(ctx, call, completed, err)
+				}
+			}
+		},
+		BeforeToolCall: func(ctx context.Context, call *ToolCall) (context.Context, error) {
+			for _, h := range hooks {
+				if h.BeforeToolCall == nil {
+					continue
+				}
+				next, err := h.BeforeToolCall(ctx, call)
+				if next != nil {
+					ctx = next
+				}
+				if err != nil {
+					return ctx, err
+				}
+			}
+			return ctx, nil
+		},
+		AfterToolCall: func(ctx context.Context, call ToolCall, output string) string {
+			for _, h := range hooks {
+				if h.AfterToolCall != nil {
+					output = h.AfterToolCall(ctx, call, output)
+				}
+			}
+			return output
+		},
+	}
+}
+
+// CallModel runs call between BeforeModelCall and AfterModelCall. call
+// returns the ModelCallCompletedResult of the response, if there was one.
+func (h Hooks) CallModel(
+	ctx context.Context,
+	modelCall ModelCall,
+	call func(ctx context.Context) (*ModelCallCompletedResult, error),
+) error {
+	var completed *ModelCallCompletedResult
+	var err error
+	if h.BeforeModelCall != nil {
+		var next context.Context
+		if next, err = h.BeforeModelCall(ctx, modelCall); next != nil {
+			ctx = next
+		}
+	}
+	if err == nil {
+		completed, err = call(ctx)
+	}
+	if h.AfterModelCall != nil {
+		if err != nil {
+			completed = nil
+		}
+		h.AfterModelCall(ctx, modelCall, completed, err)
+	}
+	return err
+}
+
+// CallTool runs the tool named call.Name between BeforeToolCall and
+// AfterToolCall and returns the output for the model. An unknown tool
+// produces an empty output.
+func (h Hooks) CallTool(ctx context.Context, tools []Tool, call ToolCall) string {
+	var err error
+	if h.BeforeToolCall != nil {
+		var next context.Context
+		if next, err = h.BeforeToolCall(ctx, &call); next != nil {
+			ctx = next
+		}
+	}
+
+	var output string
+	if err != nil {
+		output = fmt.Sprintf("error: %v", err)
+	} else if t, ok := FindTool(tools, call.Name); ok {
+		output = t.Call(context.WithValue(ctx, toolCallKey{}, call), call.Arguments)
+	}
+
+	if h.AfterToolCall != nil {
+		output = h.AfterToolCall(ctx, call, output)
+	}
+	return output
+}
+
+type toolCallKey struct{}
+
+// ToolCallFromContext returns the call being run, from the context passed
+// to Tool.FunctionContext.
+func ToolCallFromContext(ctx context.Context) (ToolCall, bool) {
+	call, ok := ctx.Value(toolCallKey{}).(ToolCall)
+	return call, ok
+}
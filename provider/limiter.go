@@ -0,0 +1,20 @@
+package provider
+
+import "context"
+
+// Limiter coordinates model calls, for example to stay below a vendor's rate
+// limits. One Limiter may be shared by several providers.
+type Limiter interface {
+	// Acquire blocks until a call expected to use about tokens tokens may
+	// start, or until ctx is done. The returned function must be called
+	// once the call has finished, with the number of tokens it actually
+	// used.
+	Acquire(ctx context.Context, tokens int) (release func(usedTokens int), err error)
+}
+
+// EstimateTokens roughly estimates the number of tokens in text, for use
+// with a Limiter before the actual usage is known. It counts four bytes per
+// token, which also gives a fair estimate for non-Latin scripts.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
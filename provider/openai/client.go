@@ -2,7 +2,12 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/demouth/orenoagent-go/provider"
 	"github.com/openai/openai-go/v3"
@@ -10,6 +15,10 @@ import (
 	"github.com/openai/openai-go/v3/responses"
 )
 
+// defaultInstructions is the system prompt sent with every conversation.
+const defaultInstructions = `1. [MUST] Provide answers and reasoning in the language the user speaks to you in. Example: If asked in Japanese, respond in Japanese.
+2. [MUST] Never answer with speculation.`
+
 type client struct {
 	openaiClient openai.Client
 	responseID   string
@@ -24,9 +33,35 @@ type client struct {
 	// Supported values: "low", "medium", "high"
 	reasoningEffort string
 
+	// System prompt
+	instructions string
+
 	// Model to use for the agent
 	model string
 
+	// Embedding model and vector size for Embed. Zero dimensions keeps the
+	// model's size.
+	embeddingModel      string
+	embeddingDimensions int
+
+	// Maximum number of tool rounds per message. Zero means no limit.
+	maxToolRounds int
+
+	// Retry policy for model rounds. Nil disables retries.
+	retryPolicy *provider.RetryPolicy
+
+	// Limiter shared with other providers. Nil means no limit.
+	limiter provider.Limiter
+
+	// Hooks around model and tool calls
+	hooks provider.Hooks
+
+	logger *slog.Logger
+
+	// Conversation so far in vendor-neutral form. When responseID is empty,
+	// it is sent in full with the next request.
+	history []provider.Message
+
 	latestMessageDeltaResult   *provider.MessageDeltaResult
 	latestReasoningDeltaResult *provider.ReasoningDeltaResult
 }
@@ -38,6 +73,9 @@ func newClient(openaiClient openai.Client) *client {
 		reasoningSummary: "", // empty string = not specified
 		reasoningEffort:  "", // empty string = not specified
 		model:            openai.ChatModelGPT5Nano,
+		embeddingModel:   openai.EmbeddingModelTextEmbedding3Small,
+		instructions:     defaultInstructions,
+		logger:           slog.New(slog.DiscardHandler),
 	}
 }
 
@@ -125,26 +163,33 @@ func (c *client) callAPI(
 
 	if c.getResponseID() == "" {
 		params.Input.OfInputItemList = append(
-			[]responses.ResponseInputItemUnionParam{
+			append([]responses.ResponseInputItemUnionParam{
 				{
 					OfInputMessage: &responses.ResponseInputItemMessageParam{
 						Role: "system",
 						Content: responses.ResponseInputMessageContentListParam{
 							responses.ResponseInputContentUnionParam{
 								OfInputText: &responses.ResponseInputTextParam{
-									Text: `1. [MUST] Provide answers and reasoning in the language the user speaks to you in. Example: If asked in Japanese, respond in Japanese.
-2. [MUST] Never answer with speculation.`,
+									Text: c.instructions,
 								},
 							},
 						},
 					},
 				},
-			},
+			}, historyInputItems(c.history)...),
 			params.Input.OfInputItemList...,
 		)
 	} else {
 		params.PreviousResponseID = openai.String(c.getResponseID())
 	}
+	c.logger.DebugContext(ctx, "model request",
+		"model", params.Model,
+		"tools", len(tools),
+		"input_items", len(params.Input.OfInputItemList),
+		"previous_response_id", c.getResponseID(),
+		"reasoning_effort", c.reasoningEffort,
+		"reasoning_summary", c.reasoningSummary,
+	)
 	resp := c.openaiClient.Responses.NewStreaming(ctx, params)
 
 	return resp
@@ -192,36 +237,24 @@ func (c *client) processMessageInput(
 		},
 	)
 
-	stream := c.callAPI(ctx, inputs, responses.ToolChoiceOptionsAuto)
-	if err := stream.Err(); err != nil {
+	var pending []provider.Message
+	if question != "" {
+		pending = append(pending, provider.Message{Role: provider.RoleUser, Text: question})
+	}
+	results, err := c.processRound(ctx, yield, inputs, pending)
+	if err != nil {
 		return nil, err
 	}
-	var results Results
-	for stream.Next() {
-		if err := stream.Err(); err != nil {
-			return nil, err
+
+	for rounds := 0; results.HasToolCallResult(); rounds++ {
+		if c.maxToolRounds > 0 && rounds >= c.maxToolRounds {
+			return nil, provider.ErrToolLimit
 		}
-		event := stream.Current()
-		result, err := c.handleResponse(ctx, yield, event)
+		moreResults, err := c.processFunctionCallInput(ctx, yield, results.MakeToolCallInputs())
 		if err != nil {
 			return nil, err
 		}
-		if result == nil {
-			continue
-		}
-		results = append(results, result)
-	}
-
-	for {
-		if results.HasToolCallResult() {
-			moreResults, err := c.processFunctionCallInput(ctx, yield, results.MakeToolCallInputs())
-			if err != nil {
-				return nil, err
-			}
-			results = moreResults
-		} else {
-			break
-		}
+		results = moreResults
 	}
 	return nil, nil
 }
@@ -232,13 +265,22 @@ func (c *client) processFunctionCallInput(
 	input *provider.FunctionCallInput,
 ) (Results, error) {
 	var itemList []responses.ResponseInputItemUnionParam
+	var pending []provider.Message
 	for _, param := range input.GetParams() {
-		callResult := ""
-		for _, t := range c.tools {
-			if param.FunctionName == t.Name {
-				callResult = t.Function(param.Args)
-			}
+		callResult := c.callTool(ctx, provider.ToolCall{
+			CallID:    param.CallID,
+			Name:      param.FunctionName,
+			Arguments: param.Args,
+		})
+		if !yield(provider.NewFunctionCallOutputResult(param.CallID, param.FunctionName, callResult)) {
+			return nil, errors.New("cancel iter")
 		}
+		pending = append(pending, provider.Message{
+			Role:   provider.RoleTool,
+			Text:   callResult,
+			CallID: param.CallID,
+			Name:   param.FunctionName,
+		})
 		itemList = append(itemList, responses.ResponseInputItemUnionParam{
 			OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
 				CallID: param.CallID,
@@ -251,16 +293,98 @@ func (c *client) processFunctionCallInput(
 	inputs := responses.ResponseNewParamsInputUnion{
 		OfInputItemList: itemList,
 	}
-	stream := c.callAPI(ctx, inputs, responses.ToolChoiceOptionsAuto)
+	return c.processRound(ctx, yield, inputs, pending)
+}
 
+// callTool runs a tool requested by the model and logs the call.
+func (c *client) callTool(ctx context.Context, call provider.ToolCall) string {
+	if _, ok := provider.FindTool(c.tools, call.Name); !ok {
+		c.logger.WarnContext(ctx, "unknown tool", "tool", call.Name, "call_id", call.CallID)
+	}
+	c.logger.DebugContext(ctx, "tool call", "tool", call.Name, "call_id", call.CallID, "arguments", call.Arguments)
+	start := time.Now()
+	output := c.hooks.CallTool(ctx, c.tools, call)
+	c.logger.DebugContext(ctx, "tool call done", "tool", call.Name, "call_id", call.CallID,
+		"duration", time.Since(start), "output_bytes", len(output))
+	return output
+}
+
+// processRound sends one request to the model and handles the streamed
+// response, retrying it according to the retry policy. On success, pending
+// and the model's output are appended to the history.
+func (c *client) processRound(
+	ctx context.Context,
+	yield func(provider.Result) bool,
+	inputs responses.ResponseNewParamsInputUnion,
+	pending []provider.Message,
+) (Results, error) {
 	var results Results
-	for stream.Next() {
-		if err := stream.Err(); err != nil {
+	call := func() error {
+		modelCall := provider.ModelCall{Model: c.model, Input: pending}
+		return c.hooks.CallModel(ctx, modelCall, func(ctx context.Context) (*provider.ModelCallCompletedResult, error) {
+			var err error
+			results, err = c.streamRound(ctx, yield, inputs)
+			return results.Completed(), err
+		})
+	}
+	var err error
+	if c.retryPolicy == nil {
+		err = call()
+	} else {
+		err = c.retryPolicy.Retry(ctx, yield, call)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.history = append(c.history, pending...)
+	c.history = append(c.history, results.Messages()...)
+	return results, nil
+}
+
+func (c *client) streamRound(
+	ctx context.Context,
+	yield func(provider.Result) bool,
+	inputs responses.ResponseNewParamsInputUnion,
+) (Results, error) {
+	// usedTokens starts as the estimate and is replaced by the actual usage
+	// once the response reports it.
+	var usedTokens int
+	if c.limiter != nil {
+		usedTokens = c.estimateTokens(inputs)
+		release, err := c.limiter.Acquire(ctx, usedTokens)
+		if err != nil {
 			return nil, err
 		}
+		defer func() { release(usedTokens) }()
+	}
+
+	if !yield(provider.NewModelCallStartedResult(c.model)) {
+		return nil, errors.New("cancel iter")
+	}
+
+	start := time.Now()
+	stream := c.callAPI(ctx, inputs, responses.ToolChoiceOptionsAuto)
+	defer stream.Close()
+
+	var results Results
+	defer func() {
+		if completed := results.Completed(); completed != nil {
+			usedTokens = completed.GetUsage().TotalTokens
+		}
+	}()
+	// Once output has been yielded, a failed round cannot be retried
+	// without repeating it.
+	var published bool
+	yieldOutput := func(r provider.Result) bool {
+		published = true
+		return yield(r)
+	}
+	for stream.Next() {
 		event := stream.Current()
-		result, err := c.handleResponse(ctx, yield, event)
+		result, err := c.handleResponse(ctx, yieldOutput, event)
 		if err != nil {
+			c.closeDeltaResults()
 			return nil, err
 		}
 		if result == nil {
@@ -268,14 +392,64 @@ func (c *client) processFunctionCallInput(
 		}
 		results = append(results, result)
 	}
+	if err := stream.Err(); err != nil {
+		c.closeDeltaResults()
+		c.logger.DebugContext(ctx, "model request failed", "model", c.model, "duration", time.Since(start), "error", err)
+		if published {
+			return nil, &provider.PartialOutputError{Err: toAPIError(err)}
+		}
+		return nil, toAPIError(err)
+	}
+	c.logger.DebugContext(ctx, "model response", "model", c.model, "response_id", c.getResponseID(), "duration", time.Since(start))
 	return results, nil
 }
 
+// estimateTokens estimates the tokens of a request sending inputs, counting
+// the conversation so far as part of the prompt.
+func (c *client) estimateTokens(inputs responses.ResponseNewParamsInputUnion) int {
+	history, _ := json.Marshal(historyInputItems(c.history))
+	input, _ := json.Marshal(inputs)
+	return provider.EstimateTokens(string(history) + string(input))
+}
+
+// closeDeltaResults closes delta results left open by an interrupted stream,
+// so that their subscribers do not wait forever.
+func (c *client) closeDeltaResults() {
+	if c.latestMessageDeltaResult != nil {
+		c.latestMessageDeltaResult.Close()
+	}
+	if c.latestReasoningDeltaResult != nil {
+		c.latestReasoningDeltaResult.Close()
+	}
+}
+
+// toAPIError wraps an OpenAI API error in a provider.APIError.
+func toAPIError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	var retryAfter time.Duration
+	if apiErr.Response != nil {
+		if ms, err := strconv.Atoi(apiErr.Response.Header.Get("Retry-After-Ms")); err == nil && ms > 0 {
+			retryAfter = time.Duration(ms) * time.Millisecond
+		} else {
+			retryAfter = provider.ParseRetryAfter(apiErr.Response.Header.Get("Retry-After"))
+		}
+	}
+	return &provider.APIError{
+		StatusCode: apiErr.StatusCode,
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
 func (c *client) handleResponse(
-	_ context.Context,
+	ctx context.Context,
 	yield func(provider.Result) bool,
 	event responses.ResponseStreamEventUnion,
 ) (provider.Result, error) {
+	c.logger.DebugContext(ctx, "stream event", "type", event.Type)
 
 	switch event.Type {
 
@@ -286,6 +460,9 @@ func (c *client) handleResponse(
 		case "message":
 		case "function_call":
 			item := r.Item.AsFunctionCall()
+			if item.CallID == "" {
+				c.logger.WarnContext(ctx, "function call without call_id", "tool", item.Name)
+			}
 			result := provider.NewFunctionCallResult(item.CallID, item.Name, item.Arguments)
 			if !yield(result) {
 				return nil, errors.New("cancel iter")
@@ -351,10 +528,104 @@ func (c *client) handleResponse(
 	case "response.completed":
 		t := event.AsResponseCompleted()
 		c.setResponseID(t.Response.ID)
+		return c.yieldModelCallCompleted(yield, t.Response)
 
-	default:
+	case "response.incomplete":
+		t := event.AsResponseIncomplete()
+		c.setResponseID(t.Response.ID)
+		return c.yieldModelCallCompleted(yield, t.Response)
 
+	case "response.failed":
+		t := event.AsResponseFailed()
+		if _, err := c.yieldModelCallCompleted(yield, t.Response); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("response failed: %s", t.Response.Error.Message)
+
+	default:
+		if !ignoredEvents[event.Type] {
+			c.logger.WarnContext(ctx, "unhandled stream event", "type", event.Type)
+		}
 	}
 
 	return nil, nil
 }
+
+// ignoredEvents are stream events that carry nothing the client needs.
+var ignoredEvents = map[string]bool{
+	"response.created":                       true,
+	"response.in_progress":                   true,
+	"response.queued":                        true,
+	"response.output_item.added":             true,
+	"response.function_call_arguments.delta": true,
+	"response.reasoning_text.delta":          true,
+	"response.reasoning_text.done":           true,
+	"response.output_text.annotation.added":  true,
+}
+
+func (c *client) yieldModelCallCompleted(
+	yield func(provider.Result) bool,
+	resp responses.Response,
+) (provider.Result, error) {
+	usage := provider.Usage{
+		InputTokens:     int(resp.Usage.InputTokens),
+		OutputTokens:    int(resp.Usage.OutputTokens),
+		TotalTokens:     int(resp.Usage.TotalTokens),
+		CachedTokens:    int(resp.Usage.InputTokensDetails.CachedTokens),
+		ReasoningTokens: int(resp.Usage.OutputTokensDetails.ReasoningTokens),
+	}
+	r := provider.NewModelCallCompletedResult(resp.Model, resp.ID, finishReason(resp), usage)
+	if !yield(r) {
+		return nil, errors.New("cancel iter")
+	}
+	return r, nil
+}
+
+// finishReason maps the status of a response to a provider.FinishReason.
+func finishReason(resp responses.Response) provider.FinishReason {
+	switch resp.Status {
+	case responses.ResponseStatusCompleted:
+		for _, item := range resp.Output {
+			if item.Type == "function_call" {
+				return provider.FinishReasonToolCalls
+			}
+		}
+		return provider.FinishReasonStop
+	case responses.ResponseStatusIncomplete:
+		switch resp.IncompleteDetails.Reason {
+		case "max_output_tokens":
+			return provider.FinishReasonMaxTokens
+		case "content_filter":
+			return provider.FinishReasonContentFilter
+		}
+		return provider.FinishReasonOther
+	case responses.ResponseStatusCancelled:
+		return provider.FinishReasonCancelled
+	case responses.ResponseStatusFailed:
+		return provider.FinishReasonError
+	default:
+		return provider.FinishReasonOther
+	}
+}
+
+// historyInputItems converts history into input items for a request that
+// does not continue a previous response.
+func historyInputItems(history []provider.Message) []responses.ResponseInputItemUnionParam {
+	var items []responses.ResponseInputItemUnionParam
+	for _, m := range history {
+		switch m.Role {
+		case provider.RoleUser:
+			items = append(items, responses.ResponseInputItemParamOfMessage(m.Text, responses.EasyInputMessageRoleUser))
+		case provider.RoleAssistant:
+			if m.Text != "" {
+				items = append(items, responses.ResponseInputItemParamOfMessage(m.Text, responses.EasyInputMessageRoleAssistant))
+			}
+			for _, fc := range m.FunctionCalls {
+				items = append(items, responses.ResponseInputItemParamOfFunctionCall(fc.Arguments, fc.CallID, fc.Name))
+			}
+		case provider.RoleTool:
+			items = append(items, responses.ResponseInputItemParamOfFunctionCallOutput(m.CallID, m.Text))
+		}
+	}
+	return items
+}
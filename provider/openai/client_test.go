@@ -0,0 +1,68 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+	oaprovider "github.com/demouth/orenoagent-go/provider/openai"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// TestRetryAfterPartialOutput checks that a stream failing after its first
+// delta is not sent again, since its output already reached the consumer.
+func TestRetryAfterPartialOutput(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		// A chunked body cut off after one delta ends in an unexpected EOF,
+		// which is retryable on its own.
+		events := `event: response.content_part.added
+data: {"type":"response.content_part.added","item_id":"msg_1","output_index":0,"content_index":0,"part":{"type":"output_text","text":""},"sequence_number":1}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","item_id":"msg_1","output_index":0,"content_index":0,"delta":"Hel","sequence_number":2}
+
+`
+		fmt.Fprint(buf, "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n")
+		fmt.Fprintf(buf, "%x\r\n%s\r\n", len(events), events)
+		buf.Flush()
+	}))
+	defer srv.Close()
+
+	client := openai.NewClient(option.WithBaseURL(srv.URL+"/v1/"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	p := oaprovider.NewProvider(client, oaprovider.WithRetryPolicy(provider.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	var types []string
+	err := p.ProcessMessage(context.Background(), func(r provider.Result) bool {
+		types = append(types, r.Type())
+		return true
+	}, "Hi")
+
+	var partialErr *provider.PartialOutputError
+	if !errors.As(err, &partialErr) {
+		t.Errorf("err = %v, want a PartialOutputError", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+	if fmt.Sprint(types) != "[model_call_started message_delta]" {
+		t.Errorf("results = %v, want [model_call_started message_delta]", types)
+	}
+}
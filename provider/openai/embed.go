@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"github.com/openai/openai-go/v3"
+)
+
+// maxEmbeddingInputs is the largest number of texts the API embeds in one
+// request.
+const maxEmbeddingInputs = 2048
+
+// embed returns the embeddings of texts, sending one request per batch.
+func (c *client) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingInputs {
+		batch := texts[start:min(start+maxEmbeddingInputs, len(texts))]
+		var batchVectors [][]float32
+		call := func() error {
+			var err error
+			batchVectors, err = c.embedBatch(ctx, batch)
+			return err
+		}
+		var err error
+		if c.retryPolicy == nil {
+			err = call()
+		} else {
+			// Retries are not reported, as Embed has no results to yield.
+			err = c.retryPolicy.Retry(ctx, func(provider.Result) bool { return true }, call)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batchVectors...)
+	}
+	return vectors, nil
+}
+
+func (c *client) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var usedTokens int
+	if c.limiter != nil {
+		for _, text := range texts {
+			usedTokens += provider.EstimateTokens(text)
+		}
+		release, err := c.limiter.Acquire(ctx, usedTokens)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { release(usedTokens) }()
+	}
+
+	params := openai.EmbeddingNewParams{
+		Model: c.embeddingModel,
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+	}
+	if c.embeddingDimensions > 0 {
+		params.Dimensions = openai.Int(int64(c.embeddingDimensions))
+	}
+
+	start := time.Now()
+	resp, err := c.openaiClient.Embeddings.New(ctx, params)
+	if err != nil {
+		c.logger.WarnContext(ctx, "embedding request failed", "model", c.embeddingModel, "error", err)
+		return nil, toAPIError(err)
+	}
+	usedTokens = int(resp.Usage.TotalTokens)
+	c.logger.DebugContext(ctx, "embedding request done", "model", c.embeddingModel,
+		"texts", len(texts), "tokens", usedTokens, "duration", time.Since(start))
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("openai: got %d embeddings for %d texts", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || int(data.Index) >= len(texts) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", data.Index)
+		}
+		vector := make([]float32, len(data.Embedding))
+		for i, v := range data.Embedding {
+			vector[i] = float32(v)
+		}
+		vectors[data.Index] = vector
+	}
+	return vectors, nil
+}
@@ -32,3 +32,42 @@ func (r Results) MakeToolCallInputs() *provider.FunctionCallInput {
 	}
 	return fcInput
 }
+
+// Messages converts the messages and function calls of a response into
+// history entries.
+func (r Results) Messages() []provider.Message {
+	var messages []provider.Message
+	var calls []provider.FunctionCall
+	for _, result := range r {
+		switch res := result.(type) {
+		case *provider.MessageResult:
+			messages = append(messages, provider.Message{
+				Role: provider.RoleAssistant,
+				Text: res.GetText(),
+			})
+		case *provider.FunctionCallResult:
+			calls = append(calls, provider.FunctionCall{
+				CallID:    res.GetCallID(),
+				Name:      res.GetName(),
+				Arguments: res.GetArguments(),
+			})
+		}
+	}
+	if len(calls) > 0 {
+		messages = append(messages, provider.Message{
+			Role:          provider.RoleAssistant,
+			FunctionCalls: calls,
+		})
+	}
+	return messages
+}
+
+// Completed returns the ModelCallCompletedResult of the response, or nil.
+func (r Results) Completed() *provider.ModelCallCompletedResult {
+	for _, result := range r {
+		if completed, ok := result.(*provider.ModelCallCompletedResult); ok {
+			return completed
+		}
+	}
+	return nil
+}
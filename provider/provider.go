@@ -1,6 +1,10 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // Result is the interface for provider results.
 type Result interface {
@@ -16,3 +20,31 @@ type Provider interface {
 	// SetTools sets the tools available to the provider.
 	SetTools(tools []Tool)
 }
+
+// Namer is implemented by providers that can describe themselves, for
+// example "openai:gpt-5-nano".
+type Namer interface {
+	Name() string
+}
+
+// NameOf returns the name of p, or its type name if p does not implement
+// Namer.
+func NameOf(p Provider) string {
+	if n, ok := p.(Namer); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// InstructionsProvider is implemented by providers that send a system prompt
+// with every conversation.
+type InstructionsProvider interface {
+	Provider
+
+	// Instructions returns the system prompt.
+	Instructions() string
+}
+
+// ErrToolLimit is returned by ProcessMessage when the model keeps requesting
+// tool calls after the configured maximum number of tool rounds.
+var ErrToolLimit = errors.New("tool round limit reached")
@@ -0,0 +1,200 @@
+// Package ratelimit provides a provider.Limiter that caps requests per
+// minute, tokens per minute and concurrent calls. A single Limiter can be
+// attached to several providers so that all agents in a process share the
+// same budget.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a provider.Limiter based on token buckets. Calls that cannot
+// start yet are queued and started in arrival order.
+type Limiter struct {
+	requestsPerMinute int
+	tokensPerMinute   int
+	maxInFlight       int
+
+	// Held by the call at the head of the queue while it waits.
+	turn chan struct{}
+
+	// Signalled when a call finishes.
+	released chan struct{}
+
+	mu       sync.Mutex
+	requests float64
+	tokens   float64
+	inFlight int
+	last     time.Time
+}
+
+// LimiterOption configures a Limiter.
+type LimiterOption func(*Limiter)
+
+// WithRequestsPerMinute limits the number of calls started per minute.
+// Zero (the default) means no limit.
+func WithRequestsPerMinute(n int) LimiterOption {
+	return func(l *Limiter) {
+		l.requestsPerMinute = n
+	}
+}
+
+// WithTokensPerMinute limits the number of tokens used per minute. Calls
+// reserve their estimated tokens when they start; the reservation is
+// corrected with the actual usage when they finish. Zero (the default) means
+// no limit.
+func WithTokensPerMinute(n int) LimiterOption {
+	return func(l *Limiter) {
+		l.tokensPerMinute = n
+	}
+}
+
+// WithMaxInFlight limits the number of calls running at the same time.
+// Zero (the default) means no limit.
+func WithMaxInFlight(n int) LimiterOption {
+	return func(l *Limiter) {
+		l.maxInFlight = n
+	}
+}
+
+// NewLimiter creates a new Limiter. The per-minute budgets start full.
+//
+// Example usage:
+//
+//	limiter := ratelimit.NewLimiter(
+//		ratelimit.WithRequestsPerMinute(500),
+//		ratelimit.WithTokensPerMinute(200_000),
+//		ratelimit.WithMaxInFlight(8),
+//	)
+//	p1 := openai.NewProvider(client, openai.WithLimiter(limiter))
+//	p2 := openai.NewProvider(client, openai.WithLimiter(limiter))
+func NewLimiter(opts ...LimiterOption) *Limiter {
+	l := &Limiter{
+		turn:     make(chan struct{}, 1),
+		released: make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	l.requests = float64(l.requestsPerMinute)
+	l.tokens = float64(l.tokensPerMinute)
+	l.last = time.Now()
+	return l
+}
+
+// Acquire implements provider.Limiter. It returns ctx.Err() if ctx is done
+// before the call may start.
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (func(usedTokens int), error) {
+	select {
+	case l.turn <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-l.turn }()
+
+	for {
+		wait, ok := l.tryAcquire(tokens)
+		if ok {
+			var once sync.Once
+			return func(usedTokens int) {
+				once.Do(func() { l.release(tokens, usedTokens) })
+			}, nil
+		}
+
+		// A zero wait means only a running call can free capacity.
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil, ctx.Err()
+		case <-timeout:
+		case <-l.released:
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+}
+
+// tryAcquire starts a call if there is capacity. Otherwise it returns how
+// long to wait for the buckets to refill.
+func (l *Limiter) tryAcquire(tokens int) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+
+	var wait time.Duration
+	blocked := false
+	if l.maxInFlight > 0 && l.inFlight >= l.maxInFlight {
+		blocked = true
+	}
+	if l.requestsPerMinute > 0 && l.requests < 1 {
+		blocked = true
+		wait = max(wait, refillTime(1-l.requests, l.requestsPerMinute))
+	}
+	if l.tokensPerMinute > 0 {
+		// A call larger than the whole budget waits for a full bucket.
+		need := float64(min(tokens, l.tokensPerMinute))
+		if l.tokens < need {
+			blocked = true
+			wait = max(wait, refillTime(need-l.tokens, l.tokensPerMinute))
+		}
+	}
+	if blocked {
+		return wait, false
+	}
+
+	if l.requestsPerMinute > 0 {
+		l.requests--
+	}
+	if l.tokensPerMinute > 0 {
+		l.tokens -= float64(tokens)
+	}
+	l.inFlight++
+	return 0, true
+}
+
+func (l *Limiter) release(reserved, used int) {
+	l.mu.Lock()
+	l.inFlight--
+	if l.tokensPerMinute > 0 {
+		l.refill(time.Now())
+		// The bucket may go negative when a call used more than reserved.
+		l.tokens = min(l.tokens+float64(reserved-used), float64(l.tokensPerMinute))
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.released <- struct{}{}:
+	default:
+	}
+}
+
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if l.requestsPerMinute > 0 {
+		l.requests = min(l.requests+elapsed*float64(l.requestsPerMinute), float64(l.requestsPerMinute))
+	}
+	if l.tokensPerMinute > 0 {
+		l.tokens = min(l.tokens+elapsed*float64(l.tokensPerMinute), float64(l.tokensPerMinute))
+	}
+}
+
+// refillTime returns how long it takes to refill amount at perMinute.
+func refillTime(amount float64, perMinute int) time.Duration {
+	return time.Duration(math.Ceil(amount / float64(perMinute) * float64(time.Minute)))
+}
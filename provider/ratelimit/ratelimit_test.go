@@ -0,0 +1,152 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go/provider/ratelimit"
+)
+
+// tryAcquire acquires with a short deadline and reports whether the call
+// could start.
+func tryAcquire(t *testing.T, l *ratelimit.Limiter, tokens int) (func(int), bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	release, err := l.Acquire(ctx, tokens)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	return release, true
+}
+
+func TestLimiterBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ratelimit.LimiterOption
+		tokens  []int
+		allowed []bool
+	}{
+		{"no limits", nil, []int{1000, 1000, 1000}, []bool{true, true, true}},
+		{"requests per minute", []ratelimit.LimiterOption{ratelimit.WithRequestsPerMinute(2)}, []int{1, 1, 1}, []bool{true, true, false}},
+		{"tokens per minute", []ratelimit.LimiterOption{ratelimit.WithTokensPerMinute(100)}, []int{60, 30, 20}, []bool{true, true, false}},
+		{"call larger than budget", []ratelimit.LimiterOption{ratelimit.WithTokensPerMinute(100)}, []int{500}, []bool{true}},
+		{"in flight", []ratelimit.LimiterOption{ratelimit.WithMaxInFlight(2)}, []int{1, 1, 1}, []bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := ratelimit.NewLimiter(tt.opts...)
+			for i, tokens := range tt.tokens {
+				// The calls stay in flight until the end of the test.
+				_, ok := tryAcquire(t, l, tokens)
+				if ok != tt.allowed[i] {
+					t.Errorf("call %d with %d tokens: started = %v, want %v", i, tokens, ok, tt.allowed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterReleaseCorrectsTokens(t *testing.T) {
+	l := ratelimit.NewLimiter(ratelimit.WithTokensPerMinute(100))
+
+	release, ok := tryAcquire(t, l, 80)
+	if !ok {
+		t.Fatal("first call did not start")
+	}
+	if _, ok := tryAcquire(t, l, 50); ok {
+		t.Fatal("second call started over budget")
+	}
+
+	// The first call used less than it reserved; releasing twice counts once.
+	release(10)
+	release(10)
+	if _, ok := tryAcquire(t, l, 80); !ok {
+		t.Error("call did not start after the unused tokens were returned")
+	}
+	if _, ok := tryAcquire(t, l, 20); ok {
+		t.Error("call started although the budget was used up")
+	}
+}
+
+func TestLimiterOrder(t *testing.T) {
+	l := ratelimit.NewLimiter(ratelimit.WithMaxInFlight(1))
+	ctx := context.Background()
+	release, err := l.Acquire(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(ctx, 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			release(0)
+		}()
+		// Let the call queue up before the next one arrives.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	release(0)
+	wg.Wait()
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(order, want) {
+		t.Errorf("calls started in order %v, want %v", order, want)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := ratelimit.NewLimiter(ratelimit.WithMaxInFlight(1))
+	release, err := l.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One call waits at the head of the queue and one behind it; both give up.
+	headCtx, cancelHead := context.WithCancel(context.Background())
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, err := l.Acquire(headCtx, 0)
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		_, err := l.Acquire(queuedCtx, 0)
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancelQueued()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("queued call: err = %v, want context.Canceled", err)
+	}
+	cancelHead()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("waiting call: err = %v, want context.Canceled", err)
+	}
+
+	// Cancelled calls leave no trace: the next call starts once the running
+	// one finishes.
+	release(0)
+	if _, ok := tryAcquire(t, l, 0); !ok {
+		t.Error("call did not start after the cancelled calls")
+	}
+}
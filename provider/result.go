@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"time"
+
 	"github.com/demouth/orenoagent-go/util"
 )
 
@@ -169,3 +171,288 @@ func (r *FunctionCallResult) GetName() string {
 func (r *FunctionCallResult) GetArguments() string {
 	return r.arguments
 }
+
+// FunctionCallOutputResult is emitted after a tool requested by the model
+// has run.
+type FunctionCallOutputResult struct {
+	callID string
+	name   string
+	output string
+}
+
+// NewFunctionCallOutputResult creates a new FunctionCallOutputResult.
+func NewFunctionCallOutputResult(callID, name, output string) *FunctionCallOutputResult {
+	return &FunctionCallOutputResult{
+		callID: callID,
+		name:   name,
+		output: output,
+	}
+}
+
+func (r *FunctionCallOutputResult) Type() string {
+	return "function_call_output"
+}
+
+// GetCallID returns the ID of the call that produced the output.
+func (r *FunctionCallOutputResult) GetCallID() string {
+	return r.callID
+}
+
+// GetName returns the function name.
+func (r *FunctionCallOutputResult) GetName() string {
+	return r.name
+}
+
+// GetOutput returns the output sent back to the model.
+func (r *FunctionCallOutputResult) GetOutput() string {
+	return r.output
+}
+
+// FinishReason describes why a model call or an agent run finished.
+type FinishReason string
+
+const (
+	// FinishReasonStop means the model finished its answer normally.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonToolCalls means the model stopped to request tool calls.
+	FinishReasonToolCalls FinishReason = "tool_calls"
+	// FinishReasonMaxTokens means the output token limit was reached.
+	FinishReasonMaxTokens FinishReason = "max_tokens"
+	// FinishReasonContentFilter means the output was blocked by a safety filter.
+	FinishReasonContentFilter FinishReason = "content_filter"
+	// FinishReasonCancelled means the run was cancelled by the caller.
+	FinishReasonCancelled FinishReason = "cancelled"
+	// FinishReasonToolLimit means the maximum number of tool rounds was reached.
+	FinishReasonToolLimit FinishReason = "tool_limit"
+	// FinishReasonError means the run ended with an error.
+	FinishReasonError FinishReason = "error"
+	// FinishReasonOther is used for reasons that do not map to any of the above.
+	FinishReasonOther FinishReason = "other"
+)
+
+// ModelCallStartedResult is emitted before a request is sent to the model.
+type ModelCallStartedResult struct {
+	model string
+}
+
+// NewModelCallStartedResult creates a new ModelCallStartedResult.
+func NewModelCallStartedResult(model string) *ModelCallStartedResult {
+	return &ModelCallStartedResult{
+		model: model,
+	}
+}
+
+func (r *ModelCallStartedResult) Type() string {
+	return "model_call_started"
+}
+
+// GetModel returns the requested model name.
+func (r *ModelCallStartedResult) GetModel() string {
+	return r.model
+}
+
+// Usage is the number of tokens used by a model call, as reported by the API.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+	TotalTokens  int
+
+	// CachedTokens is the part of InputTokens read from the prompt cache.
+	CachedTokens int
+
+	// ReasoningTokens is the part of OutputTokens spent on reasoning.
+	ReasoningTokens int
+}
+
+// ModelCallCompletedResult is emitted when the model has finished a response.
+type ModelCallCompletedResult struct {
+	model        string
+	responseID   string
+	finishReason FinishReason
+	usage        Usage
+}
+
+// NewModelCallCompletedResult creates a new ModelCallCompletedResult.
+func NewModelCallCompletedResult(model, responseID string, finishReason FinishReason, usage Usage) *ModelCallCompletedResult {
+	return &ModelCallCompletedResult{
+		model:        model,
+		responseID:   responseID,
+		finishReason: finishReason,
+		usage:        usage,
+	}
+}
+
+func (r *ModelCallCompletedResult) Type() string {
+	return "model_call_completed"
+}
+
+// GetModel returns the model name reported by the API.
+func (r *ModelCallCompletedResult) GetModel() string {
+	return r.model
+}
+
+// GetResponseID returns the response ID reported by the API.
+func (r *ModelCallCompletedResult) GetResponseID() string {
+	return r.responseID
+}
+
+// GetFinishReason returns why the model stopped generating.
+func (r *ModelCallCompletedResult) GetFinishReason() FinishReason {
+	return r.finishReason
+}
+
+// GetUsage returns the token usage of the call.
+func (r *ModelCallCompletedResult) GetUsage() Usage {
+	return r.usage
+}
+
+// RetryResult is emitted before a failed model call is retried.
+type RetryResult struct {
+	attempt     int
+	maxAttempts int
+	delay       time.Duration
+	err         error
+}
+
+// NewRetryResult creates a new RetryResult.
+func NewRetryResult(attempt, maxAttempts int, delay time.Duration, err error) *RetryResult {
+	return &RetryResult{
+		attempt:     attempt,
+		maxAttempts: maxAttempts,
+		delay:       delay,
+		err:         err,
+	}
+}
+
+func (r *RetryResult) Type() string {
+	return "retry"
+}
+
+// GetAttempt returns the number of the upcoming attempt, starting at 2.
+func (r *RetryResult) GetAttempt() int {
+	return r.attempt
+}
+
+// GetMaxAttempts returns the maximum number of attempts.
+func (r *RetryResult) GetMaxAttempts() int {
+	return r.maxAttempts
+}
+
+// GetDelay returns how long the provider waits before retrying.
+func (r *RetryResult) GetDelay() time.Duration {
+	return r.delay
+}
+
+// GetError returns the error that caused the retry.
+func (r *RetryResult) GetError() error {
+	return r.err
+}
+
+// ProviderSwitchedResult is emitted when a wrapper provider moves the
+// conversation to another provider.
+type ProviderSwitchedResult struct {
+	from string
+	to   string
+	err  error
+}
+
+// NewProviderSwitchedResult creates a new ProviderSwitchedResult. err is the
+// error that caused the switch, or nil.
+func NewProviderSwitchedResult(from, to string, err error) *ProviderSwitchedResult {
+	return &ProviderSwitchedResult{
+		from: from,
+		to:   to,
+		err:  err,
+	}
+}
+
+func (r *ProviderSwitchedResult) Type() string {
+	return "provider_switched"
+}
+
+// GetFrom returns the name of the previous provider.
+func (r *ProviderSwitchedResult) GetFrom() string {
+	return r.from
+}
+
+// GetTo returns the name of the new provider.
+func (r *ProviderSwitchedResult) GetTo() string {
+	return r.to
+}
+
+// GetError returns the error that caused the switch, or nil.
+func (r *ProviderSwitchedResult) GetError() error {
+	return r.err
+}
+
+// RouteSelectedResult is emitted when a router provider chooses the target
+// that answers a question.
+type RouteSelectedResult struct {
+	target   string
+	provider string
+	reason   string
+}
+
+// NewRouteSelectedResult creates a new RouteSelectedResult.
+func NewRouteSelectedResult(target, provider, reason string) *RouteSelectedResult {
+	return &RouteSelectedResult{
+		target:   target,
+		provider: provider,
+		reason:   reason,
+	}
+}
+
+func (r *RouteSelectedResult) Type() string {
+	return "route_selected"
+}
+
+// GetTarget returns the name of the chosen target.
+func (r *RouteSelectedResult) GetTarget() string {
+	return r.target
+}
+
+// GetProvider returns the name of the chosen target's provider.
+func (r *RouteSelectedResult) GetProvider() string {
+	return r.provider
+}
+
+// GetReason returns why the target was chosen.
+func (r *RouteSelectedResult) GetReason() string {
+	return r.reason
+}
+
+// HandoffResult is emitted when a handoff provider transfers the
+// conversation from one agent to another.
+type HandoffResult struct {
+	from   string
+	to     string
+	reason string
+}
+
+// NewHandoffResult creates a new HandoffResult.
+func NewHandoffResult(from, to, reason string) *HandoffResult {
+	return &HandoffResult{
+		from:   from,
+		to:     to,
+		reason: reason,
+	}
+}
+
+func (r *HandoffResult) Type() string {
+	return "handoff"
+}
+
+// GetFrom returns the name of the agent that handed off.
+func (r *HandoffResult) GetFrom() string {
+	return r.from
+}
+
+// GetTo returns the name of the agent that takes over.
+func (r *HandoffResult) GetTo() string {
+	return r.to
+}
+
+// GetReason returns why the model transferred the conversation.
+func (r *HandoffResult) GetReason() string {
+	return r.reason
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is an error response from an LLM API, normalized across vendors.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// RetryAfter is the delay requested by the server, or zero.
+	RetryAfter time.Duration
+
+	// Err is the original error returned by the SDK.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// PartialOutputError is the error of a round that failed after some of its
+// output had already been yielded. Sending the round again would repeat that
+// output, so it is never retried.
+type PartialOutputError struct {
+	Err error
+}
+
+func (e *PartialOutputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialOutputError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a transient error: a rate limit, a
+// server error, a timeout or a dropped connection. A PartialOutputError is
+// never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var partialErr *PartialOutputError
+	if errors.As(err, &partialErr) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+			return true
+		}
+		return apiErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an HTTP date. It returns zero if the value is invalid.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryPolicy controls how a single model round is retried after a transient
+// error. Tools that already ran are not executed again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values less than 2 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the exponential backoff. A Retry-After value sent by
+	// the server is honored even when it is longer.
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the delay after each attempt.
+	Multiplier float64
+
+	// Jitter randomly shortens each delay by up to this fraction (0 to 1).
+	Jitter float64
+
+	// Retryable reports whether an error should be retried.
+	// Default: IsRetryable
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy returns a policy with 4 attempts and exponential backoff
+// from 1 second up to 30 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Retry calls round until it succeeds, returns a non-retryable error, or the
+// maximum number of attempts is reached. Before each retry a RetryResult is
+// yielded and the backoff delay is waited out, unless ctx is done first.
+// A PartialOutputError is returned without retrying, whatever Retryable says.
+func (p *RetryPolicy) Retry(ctx context.Context, yield func(Result) bool, round func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := round()
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		var partialErr *PartialOutputError
+		if errors.As(err, &partialErr) {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+
+		if !yield(NewRetryResult(attempt+1, p.MaxAttempts, delay, err)) {
+			return fmt.Errorf("cancelled: %w", err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
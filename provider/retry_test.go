@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"wrapped canceled", fmt.Errorf("round: %w", context.Canceled), false},
+		{"408", &APIError{StatusCode: http.StatusRequestTimeout, Err: errors.New("timeout")}, true},
+		{"409", &APIError{StatusCode: http.StatusConflict, Err: errors.New("conflict")}, true},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")}, true},
+		{"500", &APIError{StatusCode: http.StatusInternalServerError, Err: errors.New("oops")}, true},
+		{"503 wrapped", fmt.Errorf("openai: %w", &APIError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("down")}), true},
+		{"400", &APIError{StatusCode: http.StatusBadRequest, Err: errors.New("bad")}, false},
+		{"401", &APIError{StatusCode: http.StatusUnauthorized, Err: errors.New("key")}, false},
+		{"net error", &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"unexpected EOF", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"partial output", &PartialOutputError{Err: io.ErrUnexpectedEOF}, false},
+		{"other", errors.New("invalid tool"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"fraction", "0.5", 500 * time.Millisecond, 500 * time.Millisecond},
+		{"zero", "0", 0, 0},
+		{"negative", "-1", 0, 0},
+		{"garbage", "soon", 0, 0},
+		{"date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("ParseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{20, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	// Jitter only shortens the delay.
+	p.Jitter = 0.5
+	for range 100 {
+		if got := p.backoff(4); got < 2500*time.Millisecond || got > 5*time.Second {
+			t.Fatalf("backoff with jitter = %v, want between 2.5s and 5s", got)
+		}
+	}
+
+	// A multiplier below 1 keeps the delay constant.
+	p = RetryPolicy{InitialBackoff: time.Second, Multiplier: 0.5}
+	if got := p.backoff(3); got != time.Second {
+		t.Errorf("backoff with multiplier 0.5 = %v, want 1s", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	serverErr := &APIError{StatusCode: http.StatusInternalServerError, Err: errors.New("oops")}
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCalls  int
+		wantErr    error
+		wantDelays []time.Duration
+	}{
+		{"success", []error{nil}, 1, nil, nil},
+		{"recovers", []error{serverErr, serverErr, nil}, 3, nil, []time.Duration{time.Millisecond, 2 * time.Millisecond}},
+		{"gives up", []error{serverErr, serverErr, serverErr, serverErr}, 3, serverErr, []time.Duration{time.Millisecond, 2 * time.Millisecond}},
+		{"not retryable", []error{io.EOF}, 1, io.EOF, nil},
+		{"partial output", []error{&PartialOutputError{Err: serverErr}, nil}, 1, serverErr, nil},
+		{
+			"retry after beyond cap",
+			[]error{&APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 20 * time.Millisecond, Err: errors.New("slow down")}, nil},
+			2, nil, []time.Duration{20 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     5 * time.Millisecond,
+				Multiplier:     2,
+				// A PartialOutputError is not retried even when this says so.
+				Retryable: func(err error) bool { return !errors.Is(err, io.EOF) },
+			}
+			calls := 0
+			var delays []time.Duration
+			err := p.Retry(context.Background(), func(r Result) bool {
+				delays = append(delays, r.(*RetryResult).GetDelay())
+				return true
+			}, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if fmt.Sprint(delays) != fmt.Sprint(tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	serverErr := &APIError{StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}
+
+	calls := 0
+	err := p.Retry(ctx, func(Result) bool {
+		cancel()
+		return true
+	}, func() error {
+		calls++
+		return serverErr
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Retry = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+
+	// A consumer that stops reading cancels the retry.
+	calls = 0
+	err = p.Retry(context.Background(), func(Result) bool { return false }, func() error {
+		calls++
+		return serverErr
+	})
+	if !errors.Is(err, serverErr) || calls != 1 {
+		t.Errorf("Retry = %v after %d calls, want the round's error after 1", err, calls)
+	}
+}
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Rule matches questions by length and keywords. All conditions that are set
+// must match.
+type Rule struct {
+	// Target is the name of the target chosen when the rule matches.
+	Target string
+
+	// MinLength and MaxLength bound the question length in characters.
+	// Zero means no bound.
+	MinLength int
+	MaxLength int
+
+	// Keywords match when any of them appears in the question, ignoring
+	// case.
+	Keywords []string
+}
+
+func (r Rule) match(question string) (bool, string) {
+	length := utf8.RuneCountInString(question)
+	if r.MinLength > 0 && length < r.MinLength {
+		return false, ""
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return false, ""
+	}
+	if len(r.Keywords) == 0 {
+		return true, fmt.Sprintf("length %d", length)
+	}
+	lower := strings.ToLower(question)
+	for _, k := range r.Keywords {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			return true, fmt.Sprintf("keyword %q", k)
+		}
+	}
+	return false, ""
+}
+
+// RulePolicy chooses the target of the first matching rule.
+type RulePolicy struct {
+	Rules []Rule
+
+	// Default is the target used when no rule matches.
+	Default string
+}
+
+// Select implements Policy.
+func (p *RulePolicy) Select(_ context.Context, question string, _ []Target) (Decision, error) {
+	for i, rule := range p.Rules {
+		if ok, why := rule.match(question); ok {
+			return Decision{
+				Target: rule.Target,
+				Reason: fmt.Sprintf("rule %d matched: %s", i, why),
+			}, nil
+		}
+	}
+	return Decision{Target: p.Default, Reason: "no rule matched"}, nil
+}
+
+// ToolPolicy routes questions that are likely to need one of the router's
+// tools to a target that can run tools, and the other questions to Default.
+// A question needs a tool when it mentions the tool's name or one of the
+// longer words of its name or description, such as "weather" for a tool described
+// as "Tells the weather in a city". To route on other words, use a
+// RulePolicy with Keywords.
+//
+// Example usage:
+//
+//	provider := router.NewProvider(
+//		[]router.Target{
+//			{Name: "chat", Provider: openai.NewProvider(client, openai.WithModel("gpt-5-nano")), NoTools: true},
+//			{Name: "agent", Provider: openai.NewProvider(client)},
+//		},
+//		&router.ToolPolicy{Default: "chat"},
+//	)
+type ToolPolicy struct {
+	// ToolTarget is the target for questions that need a tool. When it is
+	// empty or cannot run tools, the first target that can is used.
+	ToolTarget string
+
+	// Default is the target for the other questions.
+	Default string
+
+	tools []provider.Tool
+}
+
+// SetTools implements ToolAwarePolicy.
+func (p *ToolPolicy) SetTools(tools []provider.Tool) {
+	p.tools = tools
+}
+
+// Select implements Policy.
+func (p *ToolPolicy) Select(_ context.Context, question string, targets []Target) (Decision, error) {
+	words := toolWords(question)
+	for _, tool := range p.tools {
+		word, ok := needsTool(tool, words)
+		if !ok {
+			continue
+		}
+		target, ok := p.toolTarget(targets)
+		if !ok {
+			return Decision{
+				Target: p.Default,
+				Reason: fmt.Sprintf("needs tool %q (%s), but no target can run tools", tool.Name, word),
+			}, nil
+		}
+		return Decision{
+			Target: target,
+			Reason: fmt.Sprintf("needs tool %q (%s)", tool.Name, word),
+		}, nil
+	}
+	return Decision{Target: p.Default, Reason: "no tool needed"}, nil
+}
+
+// toolTarget returns ToolTarget if it can run tools, or else the first
+// target that can.
+func (p *ToolPolicy) toolTarget(targets []Target) (string, bool) {
+	var first string
+	for _, t := range targets {
+		if t.NoTools {
+			continue
+		}
+		if t.Name == p.ToolTarget {
+			return t.Name, true
+		}
+		if first == "" {
+			first = t.Name
+		}
+	}
+	return first, first != ""
+}
+
+// minToolWordLength is the length of the shortest description word that
+// suggests a tool. Shorter words are mostly articles and prepositions.
+const minToolWordLength = 5
+
+// needsTool reports whether a question with the given words mentions the
+// tool, and describes the match.
+func needsTool(tool provider.Tool, words map[string]bool) (string, bool) {
+	name := strings.ToLower(tool.Name)
+	if words[name] {
+		return fmt.Sprintf("name %q", tool.Name), true
+	}
+	// A name such as get_weather also matches its parts.
+	candidates := strings.FieldsFunc(name, isWordSeparator)
+	candidates = append(candidates, strings.FieldsFunc(strings.ToLower(tool.Description), isWordSeparator)...)
+	for _, word := range candidates {
+		if utf8.RuneCountInString(word) >= minToolWordLength && words[word] {
+			return fmt.Sprintf("word %q", word), true
+		}
+	}
+	return "", false
+}
+
+// toolWords returns the set of lowercase words in text.
+func toolWords(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		words[w] = true
+	}
+	return words
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// ClassifierPolicy asks a small model which target suits the question best,
+// based on the targets' descriptions.
+type ClassifierPolicy struct {
+	newProvider func() provider.Provider
+
+	// Default is used when the answer does not name a target.
+	Default string
+}
+
+// NewClassifierPolicy creates a new ClassifierPolicy. newProvider is called
+// for every question, so that classifications do not share history.
+//
+// Example usage:
+//
+//	policy := router.NewClassifierPolicy(func() provider.Provider {
+//		return openai.NewProvider(client, openai.WithModel(openaiSDK.ChatModelGPT5Nano))
+//	}, "fast")
+func NewClassifierPolicy(newProvider func() provider.Provider, defaultTarget string) *ClassifierPolicy {
+	return &ClassifierPolicy{
+		newProvider: newProvider,
+		Default:     defaultTarget,
+	}
+}
+
+// Select implements Policy.
+func (p *ClassifierPolicy) Select(ctx context.Context, question string, targets []Target) (Decision, error) {
+	var b strings.Builder
+	b.WriteString("Choose the assistant best suited to answer the question below. ")
+	b.WriteString("Reply with the assistant's name only.\n\nAssistants:\n")
+	for _, t := range targets {
+		fmt.Fprintf(&b, "- %s: %s\n", t.Name, t.Description)
+	}
+	b.WriteString("\nQuestion:\n")
+	b.WriteString(question)
+
+	var answer string
+	yield := func(r provider.Result) bool {
+		if m, ok := r.(*provider.MessageResult); ok {
+			answer += m.GetText()
+		}
+		return true
+	}
+	if err := p.newProvider().ProcessMessage(ctx, yield, b.String()); err != nil {
+		return Decision{}, fmt.Errorf("classifier: %w", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	for _, t := range targets {
+		if answer == strings.ToLower(t.Name) {
+			return Decision{Target: t.Name, Reason: "classifier chose " + t.Name}, nil
+		}
+	}
+	// Accept answers with extra words around the name.
+	for _, t := range targets {
+		if strings.Contains(answer, strings.ToLower(t.Name)) {
+			return Decision{Target: t.Name, Reason: "classifier chose " + t.Name}, nil
+		}
+	}
+	return Decision{
+		Target: p.Default,
+		Reason: fmt.Sprintf("classifier answer %q names no target", answer),
+	}, nil
+}
@@ -0,0 +1,183 @@
+// Package router provides a provider.Provider that chooses among several
+// providers for every question, for example a cheap model for easy
+// questions and a reasoning model for hard ones.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Target is a provider that questions can be routed to.
+type Target struct {
+	// Name identifies the target in policies and results.
+	Name string
+
+	// Description tells a classifier what the target is good at.
+	Description string
+
+	Provider provider.Provider
+
+	// NoTools marks a target whose model cannot call tools. It is not given
+	// the router's tools.
+	NoTools bool
+}
+
+// Decision is the outcome of a Policy.
+type Decision struct {
+	// Target is the name of the chosen target.
+	Target string
+
+	// Reason explains the choice, for auditing.
+	Reason string
+}
+
+// Policy chooses the target for a question.
+type Policy interface {
+	Select(ctx context.Context, question string, targets []Target) (Decision, error)
+}
+
+// ToolAwarePolicy is implemented by policies that decide based on the
+// router's tools. The router passes its tools on whenever they are set.
+type ToolAwarePolicy interface {
+	Policy
+	SetTools(tools []provider.Tool)
+}
+
+// PolicyFunc adapts a function to the Policy interface.
+type PolicyFunc func(ctx context.Context, question string, targets []Target) (Decision, error)
+
+// Select implements Policy.
+func (f PolicyFunc) Select(ctx context.Context, question string, targets []Target) (Decision, error) {
+	return f(ctx, question, targets)
+}
+
+// Provider routes every question to the target chosen by its policy. The
+// conversation is carried over between targets that implement
+// provider.HistoryProvider.
+type Provider struct {
+	targets []Target
+	policy  Policy
+
+	// Index of the target holding the latest conversation.
+	active int
+}
+
+// NewProvider creates a new router provider. When the policy fails or names
+// an unknown target, the first target is used.
+//
+// Example usage:
+//
+//	provider := router.NewProvider(
+//		[]router.Target{
+//			{Name: "fast", Provider: openai.NewProvider(client)},
+//			{Name: "deep", Provider: openai.NewProvider(client, openai.WithModel("o3"))},
+//		},
+//		&router.RulePolicy{
+//			Rules:   []router.Rule{{Target: "deep", MinLength: 500}, {Target: "deep", Keywords: []string{"prove", "design"}}},
+//			Default: "fast",
+//		},
+//	)
+func NewProvider(targets []Target, policy Policy) provider.Provider {
+	return &Provider{
+		targets: targets,
+		policy:  policy,
+	}
+}
+
+// ProcessMessage implements provider.Provider. A RouteSelectedResult is
+// yielded before the chosen target processes the question.
+func (p *Provider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	if len(p.targets) == 0 {
+		return errors.New("router: no targets")
+	}
+
+	decision, err := p.policy.Select(ctx, question, p.targets)
+	index := p.indexOf(decision.Target)
+	switch {
+	case err != nil:
+		index = 0
+		decision.Reason = fmt.Sprintf("policy failed, using first target: %v", err)
+	case index < 0:
+		index = 0
+		decision.Reason = fmt.Sprintf("unknown target %q, using first target", decision.Target)
+	}
+	target := p.targets[index]
+
+	if index != p.active {
+		if from, ok := p.targets[p.active].Provider.(provider.HistoryProvider); ok {
+			if to, ok := target.Provider.(provider.HistoryProvider); ok {
+				to.SetHistory(from.History())
+			}
+		}
+		p.active = index
+	}
+
+	if !yield(provider.NewRouteSelectedResult(target.Name, provider.NameOf(target.Provider), decision.Reason)) {
+		return errors.New("cancelled")
+	}
+	return target.Provider.ProcessMessage(ctx, yield, question)
+}
+
+func (p *Provider) indexOf(name string) int {
+	for i, t := range p.targets {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetTools implements provider.Provider. Targets marked NoTools are skipped.
+func (p *Provider) SetTools(tools []provider.Tool) {
+	for _, t := range p.targets {
+		if !t.NoTools {
+			t.Provider.SetTools(tools)
+		}
+	}
+	if tp, ok := p.policy.(ToolAwarePolicy); ok {
+		tp.SetTools(tools)
+	}
+}
+
+// SetHooks implements provider.HooksProvider.
+func (p *Provider) SetHooks(hooks provider.Hooks) {
+	for _, t := range p.targets {
+		if hp, ok := t.Provider.(provider.HooksProvider); ok {
+			hp.SetHooks(hooks)
+		}
+	}
+}
+
+// Name implements provider.Namer. It returns the name of the provider that
+// answered last.
+func (p *Provider) Name() string {
+	if len(p.targets) == 0 {
+		return "router"
+	}
+	return provider.NameOf(p.targets[p.active].Provider)
+}
+
+// History implements provider.HistoryProvider.
+func (p *Provider) History() []provider.Message {
+	if len(p.targets) == 0 {
+		return nil
+	}
+	if hp, ok := p.targets[p.active].Provider.(provider.HistoryProvider); ok {
+		return hp.History()
+	}
+	return nil
+}
+
+// SetHistory implements provider.HistoryProvider.
+func (p *Provider) SetHistory(history []provider.Message) {
+	if len(p.targets) == 0 {
+		return
+	}
+	if hp, ok := p.targets[p.active].Provider.(provider.HistoryProvider); ok {
+		hp.SetHistory(history)
+	}
+}
@@ -1,11 +1,38 @@
 package provider
 
+import "context"
+
 // Tool represents a tool that can be used by the agent.
 type Tool struct {
 	Name        string
 	Description string
 	Function    func(string) string
 	Parameters  map[string]any
+
+	// FunctionContext is used instead of Function when set. ctx is the
+	// context of the run, and ToolCallFromContext returns the call.
+	FunctionContext func(ctx context.Context, arguments string) string
+}
+
+// Call runs the tool with arguments as a JSON string.
+func (t Tool) Call(ctx context.Context, arguments string) string {
+	if t.FunctionContext != nil {
+		return t.FunctionContext(ctx, arguments)
+	}
+	if t.Function == nil {
+		return ""
+	}
+	return t.Function(arguments)
+}
+
+// FindTool returns the tool with the given name.
+func FindTool(tools []Tool, name string) (Tool, bool) {
+	for _, t := range tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tool{}, false
 }
 
 // Input represents input to the provider.
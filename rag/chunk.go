@@ -0,0 +1,161 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Chunk is a passage of a document, the unit that is embedded and
+// retrieved.
+type Chunk struct {
+	// ID is the document ID followed by "#" and the position of the chunk,
+	// starting at 1.
+	ID         string `json:"id"`
+	DocumentID string `json:"document_id"`
+	Source     string `json:"source"`
+	Title      string `json:"title,omitempty"`
+
+	// Heading is the path of headings above the chunk, for example
+	// "Setup > Linux". It is set by ByHeading.
+	Heading string `json:"heading,omitempty"`
+
+	Text string `json:"text"`
+}
+
+// Chunker splits a document into chunks.
+type Chunker func(doc Document) []Chunk
+
+// FixedSize splits documents into chunks of at most size characters, where
+// consecutive chunks share about overlap characters. Chunks end at a
+// paragraph, line or word boundary where possible.
+func FixedSize(size, overlap int) Chunker {
+	return func(doc Document) []Chunk {
+		var chunks []Chunk
+		for _, text := range splitText(doc.Text, size, overlap) {
+			chunks = appendChunk(chunks, doc, "", text)
+		}
+		return chunks
+	}
+}
+
+// ByHeading splits Markdown documents, and HTML documents loaded by
+// ParseHTML, into one chunk per section. Sections longer than maxSize
+// characters are split further like FixedSize. Each chunk starts with its
+// heading, so that the heading is embedded with the text.
+func ByHeading(maxSize int) Chunker {
+	return func(doc Document) []Chunk {
+		var chunks []Chunk
+		var headings []string
+		var section strings.Builder
+		var inFence bool
+
+		flush := func() {
+			text := strings.TrimSpace(section.String())
+			section.Reset()
+			heading := strings.Join(headings, " > ")
+			// Skip sections that are only a heading.
+			if text == "" || (len(headings) > 0 && !strings.Contains(text, "\n")) {
+				return
+			}
+			for _, part := range splitText(text, maxSize, maxSize/10) {
+				chunks = appendChunk(chunks, doc, heading, part)
+			}
+		}
+
+		for line := range strings.Lines(doc.Text) {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+			}
+			if level, title := headingOf(trimmed); level > 0 && !inFence {
+				flush()
+				if level > len(headings) {
+					level = len(headings) + 1
+				}
+				headings = append(headings[:level-1], title)
+			}
+			section.WriteString(line)
+		}
+		flush()
+		return chunks
+	}
+}
+
+// headingOf returns the level and title of a Markdown ATX heading, or zero
+// if line is not a heading.
+func headingOf(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || (line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, title
+}
+
+func appendChunk(chunks []Chunk, doc Document, heading, text string) []Chunk {
+	return append(chunks, Chunk{
+		ID:         fmt.Sprintf("%s#%d", doc.ID, len(chunks)+1),
+		DocumentID: doc.ID,
+		Source:     doc.Source,
+		Title:      doc.Title,
+		Heading:    heading,
+		Text:       text,
+	})
+}
+
+// splitText splits text into parts of at most size runes that overlap by
+// about overlap runes. Parts end at the last paragraph, line or word
+// boundary in their second half, if there is one.
+func splitText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	if size <= 0 || len(runes) <= size {
+		if len(runes) == 0 {
+			return nil
+		}
+		return []string{string(runes)}
+	}
+	overlap = max(0, min(overlap, size/2))
+
+	var parts []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = boundary(runes, start+size/2, end)
+		}
+		if part := strings.TrimSpace(string(runes[start:end])); part != "" {
+			parts = append(parts, part)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// Start the next part at a word boundary within the overlap.
+		next := end - overlap
+		for next < end && next > start && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = max(next, start+1)
+	}
+	return parts
+}
+
+// boundary returns the best position in runes[from:to] to end a part: after
+// a blank line, a line break or a space, in that order of preference.
+func boundary(runes []rune, from, to int) int {
+	for _, isBoundary := range []func(i int) bool{
+		func(i int) bool { return runes[i-1] == '\n' && i >= 2 && runes[i-2] == '\n' },
+		func(i int) bool { return runes[i-1] == '\n' },
+		func(i int) bool { return unicode.IsSpace(runes[i-1]) },
+	} {
+		for i := to; i > from; i-- {
+			if isBoundary(i) {
+				return i
+			}
+		}
+	}
+	return to
+}
@@ -0,0 +1,250 @@
+// Package rag provides retrieval-augmented generation: loading documents,
+// splitting them into chunks, indexing the chunks in an in-memory vector
+// store and retrieving the chunks relevant to a question.
+//
+// A Retriever implements orenoagent.Retriever, so that an agent can use it
+// with orenoagent.WithRetrieval, either as a search tool or by adding the
+// retrieved chunks to every question.
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Document is a text to index, such as a file.
+type Document struct {
+	// ID identifies the document in the store. Indexing a document again
+	// replaces its chunks.
+	ID string
+
+	// Source is shown in citations, for example a path or a URL.
+	Source string
+
+	Title string
+	Text  string
+}
+
+// LoadFile loads a text, Markdown or HTML file, chosen by its extension. The
+// path is used as the ID and the source.
+func LoadFile(path string) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return ParseMarkdown(path, f)
+	case ".html", ".htm":
+		return ParseHTML(path, f)
+	default:
+		return ParseText(path, f)
+	}
+}
+
+// LoadDir loads the files in dir and its subdirectories whose extension is
+// one of exts, for example ".md". All files are loaded if exts is empty.
+func LoadDir(dir string, exts ...string) ([]Document, error) {
+	var docs []Document
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if len(exts) > 0 && !containsFold(exts, filepath.Ext(path)) {
+			return nil
+		}
+		doc, err := LoadFile(path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+		return nil
+	})
+	return docs, err
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseText reads a plain text document. The title is the file name.
+func ParseText(source string, r io.Reader) (Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{
+		ID:     source,
+		Source: source,
+		Title:  filepath.Base(source),
+		Text:   string(data),
+	}, nil
+}
+
+var markdownTitle = regexp.MustCompile(`(?m)^#[ \t]+(.+?)[ \t#]*$`)
+
+// ParseMarkdown reads a Markdown document. The title is the first
+// top-level heading, or the file name if there is none.
+func ParseMarkdown(source string, r io.Reader) (Document, error) {
+	doc, err := ParseText(source, r)
+	if err != nil {
+		return Document{}, err
+	}
+	if m := markdownTitle.FindStringSubmatch(doc.Text); m != nil {
+		doc.Title = m[1]
+	}
+	return doc, nil
+}
+
+// ParseHTML reads an HTML document and keeps its visible text. Headings are
+// converted to Markdown headings so that ByHeading can split the text. The
+// title is the <title> element, or the file name if there is none.
+func ParseHTML(source string, r io.Reader) (Document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return Document{}, fmt.Errorf("rag: parse %s: %w", source, err)
+	}
+
+	doc := Document{
+		ID:     source,
+		Source: source,
+		Title:  filepath.Base(source),
+	}
+	var b htmlText
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.text(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "head":
+				if t := findElement(n, "title"); t != nil {
+					doc.Title = strings.TrimSpace(textContent(t))
+				}
+				return
+			case "script", "style", "noscript", "template":
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				b.block()
+				b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+				b.WriteString(strings.Join(strings.Fields(textContent(n)), " "))
+				b.block()
+				return
+			case "pre":
+				// Preformatted text such as code keeps its lines.
+				b.block()
+				b.WriteString(strings.Trim(textContent(n), "\n"))
+				b.block()
+				return
+			case "br":
+				b.line()
+				return
+			case "li":
+				b.line()
+				b.WriteString("- ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			b.block()
+		}
+	}
+	walk(root)
+	doc.Text = strings.TrimSpace(b.String())
+	return doc, nil
+}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "nav": true, "aside": true, "ul": true,
+	"ol": true, "table": true, "tr": true, "blockquote": true,
+	"dl": true, "dt": true, "dd": true, "figure": true, "form": true,
+}
+
+// htmlText collects text, collapsing whitespace like a browser does.
+type htmlText struct {
+	bytes.Buffer
+	space bool
+}
+
+func (b *htmlText) text(s string) {
+	if s == "" {
+		return
+	}
+	for i, field := range strings.Fields(s) {
+		if (i > 0 || b.space || unicode.IsSpace(rune(s[0]))) && b.Len() > 0 && !b.endsWith('\n') {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field)
+		b.space = false
+	}
+	if unicode.IsSpace(rune(s[len(s)-1])) {
+		b.space = true
+	}
+}
+
+func (b *htmlText) line() {
+	if b.Len() > 0 && !b.endsWith('\n') {
+		b.WriteByte('\n')
+	}
+	b.space = false
+}
+
+func (b *htmlText) block() {
+	b.line()
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n\n")) {
+		b.WriteByte('\n')
+	}
+}
+
+func (b *htmlText) endsWith(c byte) bool {
+	data := b.Bytes()
+	return len(data) > 0 && data[len(data)-1] == c
+}
+
+func findElement(n *html.Node, name string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == name {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
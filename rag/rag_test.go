@@ -0,0 +1,297 @@
+package rag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demouth/orenoagent-go/rag"
+)
+
+func TestFixedSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		size    int
+		overlap int
+		want    []string
+	}{
+		{"short text", "  One chunk.  ", 100, 10, []string{"One chunk."}},
+		{"empty", " \n ", 100, 10, nil},
+		{"no limit", "Any length.", 0, 0, []string{"Any length."}},
+		{
+			"ends at a paragraph",
+			"First paragraph here.\n\nSecond paragraph that is longer.",
+			40, 0,
+			[]string{"First paragraph here.", "Second paragraph that is longer."},
+		},
+		{
+			"prefers a line break to a space",
+			"alpha beta gamma\ndelta epsilon zeta eta",
+			24, 0,
+			[]string{"alpha beta gamma", "delta epsilon zeta eta"},
+		},
+		{
+			"ends at a word",
+			"one two three four five six seven eight",
+			20, 0,
+			[]string{"one two three four", "five six seven eight"},
+		},
+		{
+			"overlaps at a word",
+			"one two three four five six seven eight",
+			20, 6,
+			[]string{"one two three four", "four five six seven", "seven eight"},
+		},
+		{
+			"no boundary",
+			"abcdefghijklmnopqrstuvwxyz",
+			10, 0,
+			[]string{"abcdefghij", "klmnopqrst", "uvwxyz"},
+		},
+		{"counts runes", "あいうえおかきくけこ", 5, 0, []string{"あいうえお", "かきくけこ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := rag.Document{ID: "doc", Source: "doc.txt", Title: "Doc", Text: tt.text}
+			chunks := rag.FixedSize(tt.size, tt.overlap)(doc)
+			var got []string
+			for i, c := range chunks {
+				got = append(got, c.Text)
+				if want := fmt.Sprintf("doc#%d", i+1); c.ID != want || c.DocumentID != "doc" || c.Source != "doc.txt" || c.Title != "Doc" {
+					t.Errorf("chunk %d = %+v, want ID %s and the document's fields", i, c, want)
+				}
+				if n := len([]rune(c.Text)); tt.size > 0 && n > tt.size {
+					t.Errorf("chunk %d has %d runes, more than %d", i, n, tt.size)
+				}
+			}
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByHeading(t *testing.T) {
+	text := `# Guide
+
+Intro text.
+
+## Setup
+
+### Linux
+
+Run make.
+
+` + "```sh\n# not a heading\nmake install\n```" + `
+
+## Usage ##
+
+Call Run.
+
+#notaheading either
+`
+	chunks := rag.ByHeading(1000)(rag.Document{ID: "guide.md", Text: text})
+
+	type section struct{ heading, text string }
+	want := []section{
+		{"Guide", "# Guide\n\nIntro text."},
+		// "Setup" is only a heading and gets no chunk of its own.
+		{"Guide > Setup > Linux", "### Linux\n\nRun make.\n\n```sh\n# not a heading\nmake install\n```"},
+		{"Guide > Usage", "## Usage ##\n\nCall Run.\n\n#notaheading either"},
+	}
+	var got []section
+	for _, c := range chunks {
+		got = append(got, section{c.Heading, c.Text})
+	}
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("sections =\n%q\nwant\n%q", got, want)
+	}
+
+	// Long sections are split, and every part keeps the heading path.
+	long := "# Title\n\n" + strings.Repeat("word ", 100)
+	parts := rag.ByHeading(100)(rag.Document{ID: "long.md", Text: long})
+	if len(parts) < 5 {
+		t.Fatalf("long section split into %d chunks", len(parts))
+	}
+	for _, p := range parts {
+		if p.Heading != "Title" || len([]rune(p.Text)) > 100 {
+			t.Errorf("part %s: heading %q, %d runes", p.ID, p.Heading, len([]rune(p.Text)))
+		}
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head><title> Setup  guide </title><style>p { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<h1>Setup   <em>guide</em></h1>
+<p>Install   the
+   <b>tool</b>, then run it.<br>Done.</p>
+<script>alert("hidden")</script>
+<ul><li>first</li><li>second <code>x</code></li></ul>
+<pre>line 1
+  line 2</pre>
+</body>
+</html>`
+	doc, err := rag.ParseHTML("docs/setup.html", strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ID != "docs/setup.html" || doc.Title != "Setup  guide" {
+		t.Errorf("ID = %q, title = %q", doc.ID, doc.Title)
+	}
+	want := "Home\n\n# Setup guide\n\nInstall the tool, then run it.\nDone.\n\n- first\n- second x\n\nline 1\n  line 2"
+	if doc.Text != want {
+		t.Errorf("text =\n%q\nwant\n%q", doc.Text, want)
+	}
+
+	// Without a <title>, the title is the file name.
+	doc, err = rag.ParseHTML("docs/bare.html", strings.NewReader("<p>Text</p>"))
+	if err != nil || doc.Title != "bare.html" || doc.Text != "Text" {
+		t.Errorf("bare page = %+v, %v", doc, err)
+	}
+}
+
+// chunks returns chunks of a document with IDs doc#1, doc#2, ...
+func chunks(doc string, n int) []rag.Chunk {
+	var cs []rag.Chunk
+	for i := range n {
+		cs = append(cs, rag.Chunk{ID: fmt.Sprintf("%s#%d", doc, i+1), DocumentID: doc, Text: fmt.Sprintf("%s %d", doc, i+1)})
+	}
+	return cs
+}
+
+func TestStoreReplaceDocument(t *testing.T) {
+	s := rag.NewStore()
+	if err := s.Add(chunks("a", 2), [][]float32{{1, 0}, {0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(chunks("b", 1), [][]float32{{1, 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		chunks  []rag.Chunk
+		vectors [][]float32
+	}{
+		{"missing vector", chunks("a", 2), [][]float32{{1, 0}}},
+		{"empty vector", chunks("a", 1), [][]float32{{}}},
+		{"other dimensions than the store", chunks("a", 1), [][]float32{{1, 0, 0}}},
+		{"mixed dimensions", chunks("a", 2), [][]float32{{1, 0}, {1, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.ReplaceDocument("a", tt.chunks, tt.vectors); err == nil {
+				t.Fatal("ReplaceDocument accepted invalid vectors")
+			}
+			// The old chunks are kept.
+			if s.Len() != 3 {
+				t.Errorf("Len = %d, want 3", s.Len())
+			}
+			if m := s.Search([]float32{0, 1}, 1); len(m) != 1 || m[0].Chunk.ID != "a#2" {
+				t.Errorf("Search = %v, want a#2", m)
+			}
+		})
+	}
+
+	// A valid replacement drops the chunks that are gone.
+	if err := s.ReplaceDocument("a", chunks("a", 1), [][]float32{{0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len = %d, want 2", s.Len())
+	}
+
+	// A document that is the whole store may change the dimensions.
+	only := rag.NewStore()
+	only.Add(chunks("a", 1), [][]float32{{1, 0}})
+	if err := only.ReplaceDocument("a", chunks("a", 1), [][]float32{{1, 0, 0}}); err != nil {
+		t.Errorf("replacing the only document with new dimensions: %v", err)
+	}
+}
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	s := rag.NewStore()
+	s.Add(chunks("a", 3), [][]float32{{1, 0}, {0, 1}, {1, 1}})
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rag.LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := []float32{0.2, 1}
+	if got, want := fmt.Sprint(loaded.Search(query, 3)), fmt.Sprint(s.Search(query, 3)); got != want {
+		t.Errorf("loaded store finds %s, want %s", got, want)
+	}
+
+	// IDs are indexed again, so adding a chunk with a known ID replaces it.
+	loaded.Add(chunks("a", 1), [][]float32{{0, 1}})
+	if loaded.Len() != 3 {
+		t.Errorf("Len after replacing a chunk = %d, want 3", loaded.Len())
+	}
+}
+
+// fakeEmbedder embeds texts as counts of a few words.
+type fakeEmbedder struct {
+	err error
+}
+
+var vocabulary = []string{"cat", "dog", "fish"}
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, len(vocabulary))
+		for j, w := range vocabulary {
+			v[j] = float32(strings.Count(strings.ToLower(text), w))
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func TestRetriever(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	r := rag.NewRetriever(embedder, rag.NewStore(), rag.WithTopK(3), rag.WithMinScore(0.4))
+	docs := []rag.Document{
+		{ID: "pets.md", Source: "pets.md", Title: "Pets", Text: "# Pets\n\n## Cats\n\nA cat purrs.\n\n## Dogs\n\nA dog barks at a cat."},
+		{ID: "sea.md", Source: "sea.md", Title: "Sea", Text: "# Sea\n\nFish swim."},
+	}
+	if err := r.Index(context.Background(), docs...); err != nil {
+		t.Fatal(err)
+	}
+
+	citations, err := r.Retrieve(context.Background(), "cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range citations {
+		got = append(got, c.ID+" "+c.Title)
+	}
+	if want := []string{"pets.md#1 Pets > Cats", "pets.md#2 Pets > Dogs"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("citations = %q, want %q", got, want)
+	}
+
+	// A failed embedding keeps the indexed chunks.
+	embedder.err = errors.New("quota")
+	if err := r.Index(context.Background(), docs[1]); err == nil {
+		t.Error("Index succeeded without embeddings")
+	}
+	if r.Store().Len() != 3 {
+		t.Errorf("Len = %d, want 3", r.Store().Len())
+	}
+}
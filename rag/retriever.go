@@ -0,0 +1,155 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Retriever indexes documents in a Store and retrieves the chunks relevant
+// to a query. It implements orenoagent.Retriever.
+type Retriever struct {
+	embedder provider.Embedder
+	store    *Store
+	chunker  Chunker
+	topK     int
+	minScore float64
+}
+
+// RetrieverOption configures a Retriever.
+type RetrieverOption func(*Retriever)
+
+// WithChunker sets how documents are split by Index.
+// Default: ByHeading(2000)
+func WithChunker(chunker Chunker) RetrieverOption {
+	return func(r *Retriever) {
+		r.chunker = chunker
+	}
+}
+
+// WithTopK sets the number of chunks returned by Search and Retrieve.
+// Default: 4
+func WithTopK(k int) RetrieverOption {
+	return func(r *Retriever) {
+		r.topK = k
+	}
+}
+
+// WithMinScore drops chunks whose cosine similarity to the query is lower
+// than score.
+// Default: 0
+func WithMinScore(score float64) RetrieverOption {
+	return func(r *Retriever) {
+		r.minScore = score
+	}
+}
+
+// NewRetriever creates a new Retriever that embeds documents and queries
+// with embedder and keeps the chunks in store. Use the same embedding model
+// for a store loaded from disk as the one it was built with.
+//
+// Example usage:
+//
+//	prov := openai.NewProvider(client)
+//	retriever := rag.NewRetriever(prov.(provider.Embedder), rag.NewStore())
+//	docs, err := rag.LoadDir("docs", ".md", ".html")
+//	err = retriever.Index(ctx, docs...)
+//	agent := orenoagent.NewAgent(prov, orenoagent.WithRetrieval(retriever))
+func NewRetriever(embedder provider.Embedder, store *Store, opts ...RetrieverOption) *Retriever {
+	r := &Retriever{
+		embedder: embedder,
+		store:    store,
+		chunker:  ByHeading(2000),
+		topK:     4,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Store returns the store of the retriever.
+func (r *Retriever) Store() *Store {
+	return r.store
+}
+
+// Index splits documents into chunks, embeds them and adds them to the
+// store. The previous chunks of each document are replaced, and kept if
+// the document cannot be embedded.
+func (r *Retriever) Index(ctx context.Context, docs ...Document) error {
+	for _, doc := range docs {
+		chunks := r.chunker(doc)
+		texts := make([]string, len(chunks))
+		for i, c := range chunks {
+			texts[i] = c.Text
+		}
+
+		var vectors [][]float32
+		if len(texts) > 0 {
+			var err error
+			vectors, err = r.embedder.Embed(ctx, texts)
+			if err != nil {
+				return fmt.Errorf("rag: embed %s: %w", doc.ID, err)
+			}
+		}
+
+		if err := r.store.ReplaceDocument(doc.ID, chunks, vectors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search returns the chunks most similar to query, best first.
+func (r *Retriever) Search(ctx context.Context, query string) ([]Match, error) {
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("rag: embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("rag: got %d vectors for the query", len(vectors))
+	}
+
+	var matches []Match
+	for _, m := range r.store.Search(vectors[0], r.topK) {
+		if m.Score >= r.minScore {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// Retrieve implements orenoagent.Retriever. The citations refer to the
+// chunks by their IDs.
+func (r *Retriever) Retrieve(ctx context.Context, query string) ([]orenoagent.Citation, error) {
+	matches, err := r.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	citations := make([]orenoagent.Citation, len(matches))
+	for i, m := range matches {
+		title := m.Chunk.Title
+		// The heading path usually starts with the title.
+		if heading := m.Chunk.Heading; heading != "" {
+			if title == "" || heading == title || strings.HasPrefix(heading, title+" > ") {
+				title = heading
+			} else {
+				title += " > " + heading
+			}
+		}
+		citations[i] = orenoagent.Citation{
+			ID:     m.Chunk.ID,
+			Source: m.Chunk.Source,
+			Title:  title,
+			Text:   m.Chunk.Text,
+			Score:  m.Score,
+		}
+	}
+	return citations, nil
+}
@@ -0,0 +1,215 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/internal/atomicfile"
+	"github.com/demouth/orenoagent-go/internal/vector"
+)
+
+// Match is a chunk found by a search, with its cosine similarity to the
+// query.
+type Match struct {
+	Chunk Chunk
+	Score float64
+}
+
+// Store is an in-memory vector index of chunks, searched by cosine
+// similarity. It is safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	entries []storeEntry
+
+	// index maps chunk IDs to entries.
+	index map[string]int
+}
+
+type storeEntry struct {
+	Chunk  Chunk     `json:"chunk"`
+	Vector []float32 `json:"vector"`
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{index: map[string]int{}}
+}
+
+// Add adds chunks with their embedding vectors, replacing chunks with the
+// same ID. All vectors must have the same length. Nothing is added if any
+// vector is invalid.
+func (s *Store) Add(chunks []Chunk, vectors [][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := validate(chunks, vectors, s.dimensions()); err != nil {
+		return err
+	}
+	s.add(chunks, vectors)
+	return nil
+}
+
+// ReplaceDocument replaces the chunks of a document with chunks and their
+// embedding vectors in one step: searches see either the old or the new
+// chunks, and the old chunks are kept if any vector is invalid.
+func (s *Store) ReplaceDocument(documentID string, chunks []Chunk, vectors [][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The new chunks may change the vector length if they replace the
+	// whole store.
+	dims := 0
+	for _, e := range s.entries {
+		if e.Chunk.DocumentID != documentID {
+			dims = len(e.Vector)
+			break
+		}
+	}
+	if err := validate(chunks, vectors, dims); err != nil {
+		return err
+	}
+	s.deleteDocument(documentID)
+	s.add(chunks, vectors)
+	return nil
+}
+
+// validate checks that there is a vector of dims dimensions for every
+// chunk. Zero dims accepts any length that all vectors share.
+func validate(chunks []Chunk, vectors [][]float32, dims int) error {
+	if len(chunks) != len(vectors) {
+		return fmt.Errorf("rag: %d chunks but %d vectors", len(chunks), len(vectors))
+	}
+	for i, chunk := range chunks {
+		if len(vectors[i]) == 0 {
+			return fmt.Errorf("rag: empty vector for chunk %s", chunk.ID)
+		}
+		if dims == 0 {
+			dims = len(vectors[i])
+		}
+		if len(vectors[i]) != dims {
+			return fmt.Errorf("rag: vector of chunk %s has %d dimensions, want %d", chunk.ID, len(vectors[i]), dims)
+		}
+	}
+	return nil
+}
+
+// add adds validated chunks and vectors. s.mu must be held.
+func (s *Store) add(chunks []Chunk, vectors [][]float32) {
+	for i, chunk := range chunks {
+		// Vectors are stored normalized, so that cosine similarity is a
+		// dot product.
+		entry := storeEntry{Chunk: chunk, Vector: vector.Normalize(vectors[i])}
+		if j, ok := s.index[chunk.ID]; ok {
+			s.entries[j] = entry
+			continue
+		}
+		s.index[chunk.ID] = len(s.entries)
+		s.entries = append(s.entries, entry)
+	}
+}
+
+// dimensions returns the length of the stored vectors, or zero if the store
+// is empty.
+func (s *Store) dimensions() int {
+	if len(s.entries) == 0 {
+		return 0
+	}
+	return len(s.entries[0].Vector)
+}
+
+// DeleteDocument removes the chunks of a document.
+func (s *Store) DeleteDocument(documentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteDocument(documentID)
+}
+
+// deleteDocument removes the chunks of a document. s.mu must be held.
+func (s *Store) deleteDocument(documentID string) {
+	s.entries = slices.DeleteFunc(s.entries, func(e storeEntry) bool {
+		return e.Chunk.DocumentID == documentID
+	})
+	s.reindex()
+}
+
+func (s *Store) reindex() {
+	s.index = make(map[string]int, len(s.entries))
+	for i, e := range s.entries {
+		s.index[e.Chunk.ID] = i
+	}
+}
+
+// Len returns the number of chunks in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// Search returns the k chunks most similar to the embedding vector v, best
+// first.
+func (s *Store) Search(v []float32, k int) []Match {
+	query := vector.Normalize(v)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(query) != s.dimensions() || k <= 0 {
+		return nil
+	}
+	matches := make([]Match, 0, len(s.entries))
+	for _, e := range s.entries {
+		matches = append(matches, Match{Chunk: e.Chunk, Score: vector.Dot(query, e.Vector)})
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return matches[:min(k, len(matches))]
+}
+
+// Save writes the store to a JSON file. The file is replaced atomically.
+func (s *Store) Save(path string) error {
+	s.mu.RLock()
+	data, err := json.Marshal(s.entries)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data)
+}
+
+// LoadStore reads a store written by Save.
+//
+// Example usage:
+//
+//	store, err := rag.LoadStore("index.json")
+//	if errors.Is(err, fs.ErrNotExist) {
+//		store = rag.NewStore()
+//	}
+func LoadStore(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewStore()
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("rag: load %s: %w", path, err)
+	}
+	for _, e := range s.entries {
+		if len(e.Vector) != s.dimensions() {
+			return nil, fmt.Errorf("rag: load %s: vectors of different lengths", path)
+		}
+	}
+	s.reindex()
+	return s, nil
+}
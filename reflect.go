@@ -0,0 +1,255 @@
+package orenoagent
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// ReflectionOption configures reflection mode.
+type ReflectionOption func(*reflector)
+
+// WithCritic reviews answers with a separate agent instead of the agent's own
+// provider. Its conversation is reset before every review, and its results
+// are not published. The critic should not be used for anything else.
+func WithCritic(critic *Agent) ReflectionOption {
+	return func(r *reflector) {
+		r.critic = critic
+	}
+}
+
+// WithCriticPrompt sets the instructions for reviewing an answer. The
+// question, the answer and the tool calls are appended to it.
+// Default: a prompt checking correctness, completeness and agreement with
+// the tool results.
+func WithCriticPrompt(prompt string) ReflectionOption {
+	return func(r *reflector) {
+		r.prompt = prompt
+	}
+}
+
+// WithMaxRevisions limits how many times an answer may be revised. An answer
+// is reviewed at most n+1 times.
+// Default: 2
+func WithMaxRevisions(n int) ReflectionOption {
+	return func(r *reflector) {
+		r.maxRevisions = n
+	}
+}
+
+// WithReflection enables reflection mode. After the agent has answered, a
+// critic reviews the answer together with the tool calls and their outputs,
+// and a CritiqueResult is published. If the critic finds problems, the agent
+// revises the answer with the feedback, and the revision is reviewed again
+// until it is approved or the revisions run out. The last revision is
+// reviewed too, but its CritiqueResult is not followed by another revision.
+//
+// Without WithCritic, the agent's own provider reviews the answer. If the
+// provider implements provider.HistoryProvider, the review is removed from
+// the conversation afterwards.
+//
+// Example usage:
+//
+//	agent := orenoagent.NewAgent(provider,
+//		orenoagent.WithTools(tools),
+//		orenoagent.WithReflection(orenoagent.WithCritic(critic), orenoagent.WithMaxRevisions(3)),
+//	)
+func WithReflection(opts ...ReflectionOption) AgentOption {
+	return func(a *Agent) {
+		r := &reflector{
+			prompt:       defaultCriticPrompt,
+			maxRevisions: 2,
+		}
+		for _, opt := range opts {
+			opt(r)
+		}
+		a.reflector = r
+	}
+}
+
+type reflector struct {
+	critic       *Agent
+	prompt       string
+	maxRevisions int
+}
+
+// approvedReply is how the critic accepts an answer.
+const approvedReply = "APPROVED"
+
+const defaultCriticPrompt = `Review the answer below. Check that it is correct, that it answers the whole question, and that it agrees with the tool results. Do not use tools.`
+
+const critiqueFormat = `%s
+
+If the answer has no problems, reply with only "` + approvedReply + `". Otherwise list the problems and how to fix them.
+
+Question:
+%s
+
+Tool calls:
+%s
+Answer:
+%s`
+
+const revisePrompt = `A reviewer found problems in your answer:
+%s
+
+Revise the answer to fix them, using the tools as needed, and reply with the complete revised answer.`
+
+// maxEvidenceBytes limits each tool output shown to the critic.
+const maxEvidenceBytes = 2000
+
+// run answers question with process, then reviews and revises the answer.
+func (r *reflector) run(ctx context.Context, a *Agent, process func(context.Context, func(provider.Result) bool, string) error, yield func(provider.Result) bool, publish func(Result) bool, question string) error {
+	rec := &answerRecorder{}
+	if err := process(ctx, rec.wrap(yield), question); err != nil {
+		return err
+	}
+
+	for round := 1; ; round++ {
+		if rec.answer == "" {
+			return nil
+		}
+
+		feedback, err := r.critique(ctx, a, yield, fmt.Sprintf(critiqueFormat, r.prompt, question, rec.evidence(), rec.answer))
+		switch {
+		case ctx.Err() != nil:
+			return cmp.Or(err, ctx.Err())
+		case err != nil:
+			// The answer stands when the critic fails.
+			a.logger.WarnContext(ctx, "critique failed", "error", err)
+			return nil
+		}
+		approved := strings.HasPrefix(feedback, approvedReply)
+		if approved {
+			feedback = ""
+		}
+		if !publish(NewCritiqueResult(round, approved, feedback)) {
+			return errors.New("cancelled")
+		}
+		if approved || round > r.maxRevisions {
+			// The last revision is reviewed but not revised again.
+			return nil
+		}
+
+		rec.answer = ""
+		if err := a.prov.ProcessMessage(ctx, rec.wrap(yield), fmt.Sprintf(revisePrompt, feedback)); err != nil {
+			return err
+		}
+	}
+}
+
+// critique asks the critic to review and returns its reply.
+func (r *reflector) critique(ctx context.Context, a *Agent, yield func(provider.Result) bool, prompt string) (string, error) {
+	if r.critic != nil {
+		return askCritic(ctx, r.critic, prompt)
+	}
+
+	hp, ok := a.prov.(provider.HistoryProvider)
+	var history []provider.Message
+	if ok {
+		history = hp.History()
+		defer hp.SetHistory(history)
+	}
+
+	// Only the CritiqueResult is published for the review itself.
+	var reply string
+	err := a.prov.ProcessMessage(ctx, func(pr provider.Result) bool {
+		switch pr := pr.(type) {
+		case *provider.MessageResult:
+			reply += pr.GetText()
+			return true
+		case *provider.MessageDeltaResult:
+			return true
+		}
+		return yield(pr)
+	}, prompt)
+	return strings.TrimSpace(reply), err
+}
+
+func askCritic(ctx context.Context, critic *Agent, prompt string) (string, error) {
+	if hp, ok := critic.prov.(provider.HistoryProvider); ok {
+		hp.SetHistory(nil)
+	}
+
+	subscriber, err := critic.Ask(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	var reply string
+	var errs []error
+	for result := range subscriber.Subscribe() {
+		switch r := result.(type) {
+		case *MessageResult:
+			reply = r.String()
+		case *ErrorResult:
+			errs = append(errs, r.Error())
+		}
+	}
+	if reply == "" && len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return strings.TrimSpace(reply), nil
+}
+
+// answerRecorder keeps the final answer and the tool calls of the runs it
+// observes.
+type answerRecorder struct {
+	answer string
+	calls  []toolEvidence
+
+	// fresh is set when the next message starts a new answer.
+	fresh bool
+}
+
+type toolEvidence struct {
+	callID    string
+	name      string
+	arguments string
+	output    string
+}
+
+func (rec *answerRecorder) wrap(yield func(provider.Result) bool) func(provider.Result) bool {
+	return func(r provider.Result) bool {
+		switch r := r.(type) {
+		case *provider.ModelCallStartedResult:
+			rec.fresh = true
+		case *provider.MessageResult:
+			if rec.fresh {
+				rec.answer = ""
+				rec.fresh = false
+			}
+			rec.answer += r.GetText()
+		case *provider.FunctionCallResult:
+			rec.calls = append(rec.calls, toolEvidence{callID: r.GetCallID(), name: r.GetName(), arguments: r.GetArguments()})
+		case *provider.FunctionCallOutputResult:
+			rec.fresh = true
+			for i := len(rec.calls) - 1; i >= 0; i-- {
+				if rec.calls[i].callID == r.GetCallID() {
+					rec.calls[i].output = r.GetOutput()
+					break
+				}
+			}
+		}
+		return yield(r)
+	}
+}
+
+func (rec *answerRecorder) evidence() string {
+	if len(rec.calls) == 0 {
+		return "(none)\n"
+	}
+	var b strings.Builder
+	for _, c := range rec.calls {
+		output := c.output
+		if len(output) > maxEvidenceBytes {
+			output = output[:maxEvidenceBytes] + "..."
+		}
+		fmt.Fprintf(&b, "- %s(%s)\n  -> %s\n", c.name, c.arguments, output)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package orenoagent_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// replyProvider answers every question with the next reply, repeating the
+// last one.
+type replyProvider struct {
+	replies   []string
+	questions int
+}
+
+func (p *replyProvider) SetTools([]provider.Tool) {}
+func (p *replyProvider) ProcessMessage(_ context.Context, yield func(provider.Result) bool, _ string) error {
+	reply := p.replies[min(p.questions, len(p.replies)-1)]
+	p.questions++
+	yield(provider.NewMessageResult(reply))
+	return nil
+}
+
+func TestReflection(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRevisions int
+		verdicts     []string
+		answers      int
+		critiques    []string
+	}{
+		{"approved", 2, []string{"APPROVED"}, 1, []string{"Critique 1: approved"}},
+		{"approved revision", 2, []string{"Too short.", "APPROVED"}, 2, []string{"Critique 1: Too short.", "Critique 2: approved"}},
+		{
+			"last revision is reviewed", 1, []string{"Too short."}, 2,
+			[]string{"Critique 1: Too short.", "Critique 2: Too short."},
+		},
+		{"no revisions", 0, []string{"Too short."}, 1, []string{"Critique 1: Too short."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answerer := &replyProvider{replies: []string{"Draft.", "Revised."}}
+			critic := orenoagent.NewAgent(&replyProvider{replies: tt.verdicts})
+			agent := orenoagent.NewAgent(answerer, orenoagent.WithReflection(
+				orenoagent.WithCritic(critic),
+				orenoagent.WithMaxRevisions(tt.maxRevisions),
+			))
+			subscriber, err := agent.Ask(context.Background(), "Question?")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var critiques []string
+			for result := range subscriber.Subscribe() {
+				if r, ok := result.(*orenoagent.CritiqueResult); ok {
+					critiques = append(critiques, r.String())
+				}
+			}
+			if fmt.Sprint(critiques) != fmt.Sprint(tt.critiques) {
+				t.Errorf("critiques = %q, want %q", critiques, tt.critiques)
+			}
+			if answerer.questions != tt.answers {
+				t.Errorf("answered %d times, want %d", answerer.questions, tt.answers)
+			}
+		})
+	}
+}
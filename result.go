@@ -2,7 +2,11 @@ package orenoagent
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/demouth/orenoagent-go/provider"
 	"github.com/demouth/orenoagent-go/util"
 )
 
@@ -166,6 +170,63 @@ func (r *FunctionCallResult) String() string {
 	return "FunctionToolCall: " + r.name + " args:" + r.arguments
 }
 
+// CallID returns the ID of the call.
+func (r *FunctionCallResult) CallID() string {
+	return r.callID
+}
+
+// Name returns the function name.
+func (r *FunctionCallResult) Name() string {
+	return r.name
+}
+
+// Arguments returns the function arguments as JSON string.
+func (r *FunctionCallResult) Arguments() string {
+	return r.arguments
+}
+
+// FunctionCallOutputResult is emitted after a tool requested by the model
+// has run.
+type FunctionCallOutputResult struct {
+	callID string
+	name   string
+	output string
+}
+
+// NewFunctionCallOutputResult creates a new FunctionCallOutputResult.
+func NewFunctionCallOutputResult(callID, name, output string) *FunctionCallOutputResult {
+	return &FunctionCallOutputResult{
+		callID: callID,
+		name:   name,
+		output: output,
+	}
+}
+
+func (*FunctionCallOutputResult) isResult() {}
+
+func (r *FunctionCallOutputResult) Type() string {
+	return "function_call_output"
+}
+
+func (r *FunctionCallOutputResult) String() string {
+	return "FunctionToolOutput: " + r.name + " output:" + r.output
+}
+
+// CallID returns the ID of the call that produced the output.
+func (r *FunctionCallOutputResult) CallID() string {
+	return r.callID
+}
+
+// Name returns the function name.
+func (r *FunctionCallOutputResult) Name() string {
+	return r.name
+}
+
+// Output returns the output sent back to the model.
+func (r *FunctionCallOutputResult) Output() string {
+	return r.output
+}
+
 // ErrorResult represents an error from the agent.
 type ErrorResult struct {
 	err error
@@ -189,3 +250,562 @@ func (r *ErrorResult) String() string {
 func (r *ErrorResult) Error() error {
 	return r.err
 }
+
+// FinishReason is re-exported from provider for convenience.
+type FinishReason = provider.FinishReason
+
+// Finish reasons reported by ModelCallCompletedResult and RunCompletedResult.
+const (
+	FinishReasonStop          = provider.FinishReasonStop
+	FinishReasonToolCalls     = provider.FinishReasonToolCalls
+	FinishReasonMaxTokens     = provider.FinishReasonMaxTokens
+	FinishReasonContentFilter = provider.FinishReasonContentFilter
+	FinishReasonCancelled     = provider.FinishReasonCancelled
+	FinishReasonToolLimit     = provider.FinishReasonToolLimit
+	FinishReasonError         = provider.FinishReasonError
+	FinishReasonOther         = provider.FinishReasonOther
+)
+
+// Usage is re-exported from provider for convenience.
+type Usage = provider.Usage
+
+// RunStartedResult is the first result of every Ask.
+type RunStartedResult struct {
+	question string
+}
+
+// NewRunStartedResult creates a new RunStartedResult.
+func NewRunStartedResult(question string) *RunStartedResult {
+	return &RunStartedResult{
+		question: question,
+	}
+}
+
+func (*RunStartedResult) isResult() {}
+
+func (r *RunStartedResult) Type() string {
+	return "run_started"
+}
+
+func (r *RunStartedResult) String() string {
+	return "RunStarted: " + r.question
+}
+
+// Question returns the question passed to Ask.
+func (r *RunStartedResult) Question() string {
+	return r.question
+}
+
+// ModelCallStartedResult is emitted before each request to the model.
+type ModelCallStartedResult struct {
+	model string
+}
+
+// NewModelCallStartedResult creates a new ModelCallStartedResult.
+func NewModelCallStartedResult(model string) *ModelCallStartedResult {
+	return &ModelCallStartedResult{
+		model: model,
+	}
+}
+
+func (*ModelCallStartedResult) isResult() {}
+
+func (r *ModelCallStartedResult) Type() string {
+	return "model_call_started"
+}
+
+func (r *ModelCallStartedResult) String() string {
+	return "ModelCallStarted: " + r.model
+}
+
+// Model returns the requested model name.
+func (r *ModelCallStartedResult) Model() string {
+	return r.model
+}
+
+// ModelCallCompletedResult is emitted when the model has finished a response.
+type ModelCallCompletedResult struct {
+	model        string
+	responseID   string
+	finishReason FinishReason
+	usage        Usage
+}
+
+// NewModelCallCompletedResult creates a new ModelCallCompletedResult.
+func NewModelCallCompletedResult(model, responseID string, finishReason FinishReason, usage Usage) *ModelCallCompletedResult {
+	return &ModelCallCompletedResult{
+		model:        model,
+		responseID:   responseID,
+		finishReason: finishReason,
+		usage:        usage,
+	}
+}
+
+func (*ModelCallCompletedResult) isResult() {}
+
+func (r *ModelCallCompletedResult) Type() string {
+	return "model_call_completed"
+}
+
+func (r *ModelCallCompletedResult) String() string {
+	return fmt.Sprintf("ModelCallCompleted: %s id:%s reason:%s tokens:%d", r.model, r.responseID, r.finishReason, r.usage.TotalTokens)
+}
+
+// Model returns the model name reported by the API.
+func (r *ModelCallCompletedResult) Model() string {
+	return r.model
+}
+
+// ResponseID returns the response ID reported by the API.
+func (r *ModelCallCompletedResult) ResponseID() string {
+	return r.responseID
+}
+
+// FinishReason returns why the model stopped generating.
+func (r *ModelCallCompletedResult) FinishReason() FinishReason {
+	return r.finishReason
+}
+
+// Usage returns the token usage of the call.
+func (r *ModelCallCompletedResult) Usage() Usage {
+	return r.usage
+}
+
+// RunCompletedResult is the last result of every Ask.
+type RunCompletedResult struct {
+	finishReason FinishReason
+}
+
+// NewRunCompletedResult creates a new RunCompletedResult.
+func NewRunCompletedResult(finishReason FinishReason) *RunCompletedResult {
+	return &RunCompletedResult{
+		finishReason: finishReason,
+	}
+}
+
+func (*RunCompletedResult) isResult() {}
+
+func (r *RunCompletedResult) Type() string {
+	return "run_completed"
+}
+
+func (r *RunCompletedResult) String() string {
+	return "RunCompleted: " + string(r.finishReason)
+}
+
+// FinishReason returns why the run finished.
+func (r *RunCompletedResult) FinishReason() FinishReason {
+	return r.finishReason
+}
+
+// RetryResult is emitted before a failed model call is retried.
+type RetryResult struct {
+	attempt     int
+	maxAttempts int
+	delay       time.Duration
+	err         error
+}
+
+// NewRetryResult creates a new RetryResult.
+func NewRetryResult(attempt, maxAttempts int, delay time.Duration, err error) *RetryResult {
+	return &RetryResult{
+		attempt:     attempt,
+		maxAttempts: maxAttempts,
+		delay:       delay,
+		err:         err,
+	}
+}
+
+func (*RetryResult) isResult() {}
+
+func (r *RetryResult) Type() string {
+	return "retry"
+}
+
+func (r *RetryResult) String() string {
+	return fmt.Sprintf("Retry: attempt %d/%d in %s: %v", r.attempt, r.maxAttempts, r.delay, r.err)
+}
+
+// Attempt returns the number of the upcoming attempt, starting at 2.
+func (r *RetryResult) Attempt() int {
+	return r.attempt
+}
+
+// MaxAttempts returns the maximum number of attempts.
+func (r *RetryResult) MaxAttempts() int {
+	return r.maxAttempts
+}
+
+// Delay returns how long the provider waits before retrying.
+func (r *RetryResult) Delay() time.Duration {
+	return r.delay
+}
+
+// Error returns the error that caused the retry.
+func (r *RetryResult) Error() error {
+	return r.err
+}
+
+// ProviderSwitchedResult is emitted when the conversation moves to another
+// provider, for example after a fallback.
+type ProviderSwitchedResult struct {
+	from string
+	to   string
+	err  error
+}
+
+// NewProviderSwitchedResult creates a new ProviderSwitchedResult.
+func NewProviderSwitchedResult(from, to string, err error) *ProviderSwitchedResult {
+	return &ProviderSwitchedResult{
+		from: from,
+		to:   to,
+		err:  err,
+	}
+}
+
+func (*ProviderSwitchedResult) isResult() {}
+
+func (r *ProviderSwitchedResult) Type() string {
+	return "provider_switched"
+}
+
+func (r *ProviderSwitchedResult) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("ProviderSwitched: %s -> %s (%v)", r.from, r.to, r.err)
+	}
+	return fmt.Sprintf("ProviderSwitched: %s -> %s", r.from, r.to)
+}
+
+// From returns the name of the previous provider.
+func (r *ProviderSwitchedResult) From() string {
+	return r.from
+}
+
+// To returns the name of the new provider.
+func (r *ProviderSwitchedResult) To() string {
+	return r.to
+}
+
+// Error returns the error that caused the switch, or nil.
+func (r *ProviderSwitchedResult) Error() error {
+	return r.err
+}
+
+// RouteSelectedResult is emitted when a router chooses the provider that
+// answers a question.
+type RouteSelectedResult struct {
+	target   string
+	provider string
+	reason   string
+}
+
+// NewRouteSelectedResult creates a new RouteSelectedResult.
+func NewRouteSelectedResult(target, provider, reason string) *RouteSelectedResult {
+	return &RouteSelectedResult{
+		target:   target,
+		provider: provider,
+		reason:   reason,
+	}
+}
+
+func (*RouteSelectedResult) isResult() {}
+
+func (r *RouteSelectedResult) Type() string {
+	return "route_selected"
+}
+
+func (r *RouteSelectedResult) String() string {
+	return fmt.Sprintf("RouteSelected: %s (%s) reason:%s", r.target, r.provider, r.reason)
+}
+
+// Target returns the name of the chosen target.
+func (r *RouteSelectedResult) Target() string {
+	return r.target
+}
+
+// Provider returns the name of the chosen target's provider.
+func (r *RouteSelectedResult) Provider() string {
+	return r.provider
+}
+
+// Reason returns why the target was chosen.
+func (r *RouteSelectedResult) Reason() string {
+	return r.reason
+}
+
+// HandoffResult is emitted when the conversation is transferred from one
+// agent to another.
+type HandoffResult struct {
+	from   string
+	to     string
+	reason string
+}
+
+// NewHandoffResult creates a new HandoffResult.
+func NewHandoffResult(from, to, reason string) *HandoffResult {
+	return &HandoffResult{
+		from:   from,
+		to:     to,
+		reason: reason,
+	}
+}
+
+func (*HandoffResult) isResult() {}
+
+func (r *HandoffResult) Type() string {
+	return "handoff"
+}
+
+func (r *HandoffResult) String() string {
+	return fmt.Sprintf("Handoff: %s -> %s reason:%s", r.from, r.to, r.reason)
+}
+
+// From returns the name of the agent that handed off.
+func (r *HandoffResult) From() string {
+	return r.from
+}
+
+// To returns the name of the agent that takes over.
+func (r *HandoffResult) To() string {
+	return r.to
+}
+
+// Reason returns why the model transferred the conversation.
+func (r *HandoffResult) Reason() string {
+	return r.reason
+}
+
+// StepStatus is the progress of a PlanStep.
+type StepStatus string
+
+const (
+	StepPending StepStatus = "pending"
+	StepRunning StepStatus = "running"
+	StepDone    StepStatus = "done"
+	StepFailed  StepStatus = "failed"
+)
+
+// PlanStep is a step of a plan made in planning mode.
+type PlanStep struct {
+	Description string     `json:"description"`
+	Status      StepStatus `json:"status"`
+
+	// Output is the answer to the step, or why it failed.
+	Output string `json:"output,omitempty"`
+}
+
+// PlanResult is emitted in planning mode when a plan is made, and again with
+// the revised steps after replanning.
+type PlanResult struct {
+	steps []PlanStep
+}
+
+// NewPlanResult creates a new PlanResult.
+func NewPlanResult(steps []PlanStep) *PlanResult {
+	return &PlanResult{
+		steps: slices.Clone(steps),
+	}
+}
+
+func (*PlanResult) isResult() {}
+
+func (r *PlanResult) Type() string {
+	return "plan"
+}
+
+func (r *PlanResult) String() string {
+	var b strings.Builder
+	b.WriteString("Plan:")
+	for i, step := range r.steps {
+		fmt.Fprintf(&b, "\n%d. [%s] %s", i+1, step.Status, step.Description)
+	}
+	return b.String()
+}
+
+// Steps returns the steps of the plan.
+func (r *PlanResult) Steps() []PlanStep {
+	return slices.Clone(r.steps)
+}
+
+// PlanStepResult is emitted in planning mode when the status of a step
+// changes.
+type PlanStepResult struct {
+	index int
+	step  PlanStep
+}
+
+// NewPlanStepResult creates a new PlanStepResult.
+func NewPlanStepResult(index int, step PlanStep) *PlanStepResult {
+	return &PlanStepResult{
+		index: index,
+		step:  step,
+	}
+}
+
+func (*PlanStepResult) isResult() {}
+
+func (r *PlanStepResult) Type() string {
+	return "plan_step"
+}
+
+func (r *PlanStepResult) String() string {
+	return fmt.Sprintf("PlanStep %d [%s]: %s", r.index+1, r.step.Status, r.step.Description)
+}
+
+// Index returns the position of the step in the plan, starting at 0.
+func (r *PlanStepResult) Index() int {
+	return r.index
+}
+
+// Step returns the step with its new status.
+func (r *PlanStepResult) Step() PlanStep {
+	return r.step
+}
+
+// CritiqueResult is emitted in reflection mode when the critic has reviewed
+// an answer. When the answer is not approved and revisions remain, the revised
+// answer follows as new results of the run.
+type CritiqueResult struct {
+	round    int
+	approved bool
+	feedback string
+}
+
+// NewCritiqueResult creates a new CritiqueResult.
+func NewCritiqueResult(round int, approved bool, feedback string) *CritiqueResult {
+	return &CritiqueResult{
+		round:    round,
+		approved: approved,
+		feedback: feedback,
+	}
+}
+
+func (*CritiqueResult) isResult() {}
+
+func (r *CritiqueResult) Type() string {
+	return "critique"
+}
+
+func (r *CritiqueResult) String() string {
+	if r.approved {
+		return fmt.Sprintf("Critique %d: approved", r.round)
+	}
+	return fmt.Sprintf("Critique %d: %s", r.round, r.feedback)
+}
+
+// Round returns the number of the review, starting at 1.
+func (r *CritiqueResult) Round() int {
+	return r.round
+}
+
+// Approved reports whether the critic accepted the answer.
+func (r *CritiqueResult) Approved() bool {
+	return r.approved
+}
+
+// Feedback returns the problems the critic found.
+func (r *CritiqueResult) Feedback() string {
+	return r.feedback
+}
+
+// Citation is a source passage retrieved for a question.
+type Citation struct {
+	// ID identifies the passage, for example "docs/setup.md#2".
+	ID     string  `json:"id"`
+	Source string  `json:"source"`
+	Title  string  `json:"title,omitempty"`
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"`
+
+	// Number is what the model cites the passage as, [Number]. The agent
+	// numbers passages across a run, so a passage found again keeps its
+	// number and a later search continues where the last one stopped.
+	Number int `json:"number,omitempty"`
+}
+
+// CitationsResult is emitted when passages were retrieved for a question,
+// either before the question is sent to the model or by a search tool. The
+// model refers to them by their Number.
+type CitationsResult struct {
+	query     string
+	citations []Citation
+}
+
+// NewCitationsResult creates a new CitationsResult.
+func NewCitationsResult(query string, citations []Citation) *CitationsResult {
+	return &CitationsResult{
+		query:     query,
+		citations: slices.Clone(citations),
+	}
+}
+
+func (*CitationsResult) isResult() {}
+
+func (r *CitationsResult) Type() string {
+	return "citations"
+}
+
+func (r *CitationsResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Citations for %q:", r.query)
+	for _, c := range r.citations {
+		fmt.Fprintf(&b, "\n[%d] %s (%.2f)", c.Number, c.ID, c.Score)
+	}
+	return b.String()
+}
+
+// Query returns the text the passages were retrieved for.
+func (r *CitationsResult) Query() string {
+	return r.query
+}
+
+// Citations returns the retrieved passages, best first.
+func (r *CitationsResult) Citations() []Citation {
+	return slices.Clone(r.citations)
+}
+
+// SubAgentResult wraps a result of an agent that runs as a tool of another
+// agent. It is published in the parent's stream, nested under the parent's
+// call of the tool.
+type SubAgentResult struct {
+	parentCallID string
+	agent        string
+	result       Result
+}
+
+// NewSubAgentResult creates a new SubAgentResult.
+func NewSubAgentResult(parentCallID, agent string, result Result) *SubAgentResult {
+	return &SubAgentResult{
+		parentCallID: parentCallID,
+		agent:        agent,
+		result:       result,
+	}
+}
+
+func (*SubAgentResult) isResult() {}
+
+func (r *SubAgentResult) Type() string {
+	return "sub_agent"
+}
+
+func (r *SubAgentResult) String() string {
+	if s, ok := r.result.(fmt.Stringer); ok {
+		return fmt.Sprintf("SubAgent[%s]: %s", r.agent, s.String())
+	}
+	return fmt.Sprintf("SubAgent[%s]: %s", r.agent, r.result.Type())
+}
+
+// ParentCallID returns the ID of the parent's call of the tool.
+func (r *SubAgentResult) ParentCallID() string {
+	return r.parentCallID
+}
+
+// Agent returns the name of the tool that runs the agent.
+func (r *SubAgentResult) Agent() string {
+	return r.agent
+}
+
+// Result returns the sub-agent's result.
+func (r *SubAgentResult) Result() Result {
+	return r.result
+}
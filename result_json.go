@@ -0,0 +1,628 @@
+package orenoagent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UnmarshalResult decodes a JSON document produced by json.Marshal on any
+// Result back into its concrete type, using the "type" field as the
+// discriminator.
+//
+// Example usage:
+//
+//	data, _ := json.Marshal(result)
+//	result, err := orenoagent.UnmarshalResult(data)
+func UnmarshalResult(data []byte) (Result, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var r Result
+	switch header.Type {
+	case "message":
+		r = &MessageResult{}
+	case "message_delta":
+		r = &MessageDeltaResult{}
+	case "think":
+		r = &ReasoningResult{}
+	case "reasoning_delta_result":
+		r = &ReasoningDeltaResult{}
+	case "function_call":
+		r = &FunctionCallResult{}
+	case "function_call_output":
+		r = &FunctionCallOutputResult{}
+	case "error":
+		r = &ErrorResult{}
+	case "run_started":
+		r = &RunStartedResult{}
+	case "model_call_started":
+		r = &ModelCallStartedResult{}
+	case "model_call_completed":
+		r = &ModelCallCompletedResult{}
+	case "run_completed":
+		r = &RunCompletedResult{}
+	case "retry":
+		r = &RetryResult{}
+	case "provider_switched":
+		r = &ProviderSwitchedResult{}
+	case "route_selected":
+		r = &RouteSelectedResult{}
+	case "handoff":
+		r = &HandoffResult{}
+	case "plan":
+		r = &PlanResult{}
+	case "plan_step":
+		r = &PlanStepResult{}
+	case "critique":
+		r = &CritiqueResult{}
+	case "citations":
+		r = &CitationsResult{}
+	case "sub_agent":
+		r = &SubAgentResult{}
+	default:
+		return nil, fmt.Errorf("unknown result type: %q", header.Type)
+	}
+
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// checkType returns an error if the decoded discriminator does not match want.
+func checkType(got, want string) error {
+	if got != want {
+		return fmt.Errorf("unexpected result type: got %q, want %q", got, want)
+	}
+	return nil
+}
+
+type textResultJSON struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (r *MessageResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(textResultJSON{Type: r.Type(), Text: r.text})
+}
+
+func (r *MessageResult) UnmarshalJSON(data []byte) error {
+	var v textResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.text = v.Text
+	return nil
+}
+
+// MarshalJSON encodes the text received so far.
+func (r *MessageDeltaResult) MarshalJSON() ([]byte, error) {
+	text := strings.Join(r.subscriber.GetHistory(), "")
+	return json.Marshal(textResultJSON{Type: r.Type(), Text: text})
+}
+
+// UnmarshalJSON restores the result as a closed stream holding the whole text
+// as a single delta.
+func (r *MessageDeltaResult) UnmarshalJSON(data []byte) error {
+	var v textResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	*r = *NewMessageDeltaResult(v.Text)
+	r.Close()
+	return nil
+}
+
+func (r *ReasoningResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(textResultJSON{Type: r.Type(), Text: r.text})
+}
+
+func (r *ReasoningResult) UnmarshalJSON(data []byte) error {
+	var v textResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.text = v.Text
+	return nil
+}
+
+// MarshalJSON encodes the text received so far.
+func (r *ReasoningDeltaResult) MarshalJSON() ([]byte, error) {
+	text := strings.Join(r.subscriber.GetHistory(), "")
+	return json.Marshal(textResultJSON{Type: r.Type(), Text: text})
+}
+
+// UnmarshalJSON restores the result as a closed stream holding the whole text
+// as a single delta.
+func (r *ReasoningDeltaResult) UnmarshalJSON(data []byte) error {
+	var v textResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	*r = *NewReasoningDeltaResult(v.Text)
+	r.Close()
+	return nil
+}
+
+type functionCallResultJSON struct {
+	Type      string `json:"type"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+func (r *FunctionCallResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(functionCallResultJSON{
+		Type:      r.Type(),
+		CallID:    r.callID,
+		Name:      r.name,
+		Arguments: r.arguments,
+	})
+}
+
+func (r *FunctionCallResult) UnmarshalJSON(data []byte) error {
+	var v functionCallResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.callID = v.CallID
+	r.name = v.Name
+	r.arguments = v.Arguments
+	return nil
+}
+
+type functionCallOutputResultJSON struct {
+	Type   string `json:"type"`
+	CallID string `json:"call_id"`
+	Name   string `json:"name"`
+	Output string `json:"output"`
+}
+
+func (r *FunctionCallOutputResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(functionCallOutputResultJSON{
+		Type:   r.Type(),
+		CallID: r.callID,
+		Name:   r.name,
+		Output: r.output,
+	})
+}
+
+func (r *FunctionCallOutputResult) UnmarshalJSON(data []byte) error {
+	var v functionCallOutputResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.callID = v.CallID
+	r.name = v.Name
+	r.output = v.Output
+	return nil
+}
+
+type errorResultJSON struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// MarshalJSON encodes the error message. The error's concrete type is not
+// preserved.
+func (r *ErrorResult) MarshalJSON() ([]byte, error) {
+	msg := ""
+	if r.err != nil {
+		msg = r.err.Error()
+	}
+	return json.Marshal(errorResultJSON{Type: r.Type(), Error: msg})
+}
+
+func (r *ErrorResult) UnmarshalJSON(data []byte) error {
+	var v errorResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.err = errors.New(v.Error)
+	return nil
+}
+
+type runStartedResultJSON struct {
+	Type     string `json:"type"`
+	Question string `json:"question"`
+}
+
+func (r *RunStartedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(runStartedResultJSON{Type: r.Type(), Question: r.question})
+}
+
+func (r *RunStartedResult) UnmarshalJSON(data []byte) error {
+	var v runStartedResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.question = v.Question
+	return nil
+}
+
+type modelCallResultJSON struct {
+	Type         string       `json:"type"`
+	Model        string       `json:"model"`
+	ResponseID   string       `json:"response_id,omitempty"`
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+	Usage        *usageJSON   `json:"usage,omitempty"`
+}
+
+type usageJSON struct {
+	InputTokens     int `json:"input_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	TotalTokens     int `json:"total_tokens"`
+	CachedTokens    int `json:"cached_tokens,omitempty"`
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+func (r *ModelCallStartedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(modelCallResultJSON{Type: r.Type(), Model: r.model})
+}
+
+func (r *ModelCallStartedResult) UnmarshalJSON(data []byte) error {
+	var v modelCallResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.model = v.Model
+	return nil
+}
+
+func (r *ModelCallCompletedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(modelCallResultJSON{
+		Type:         r.Type(),
+		Model:        r.model,
+		ResponseID:   r.responseID,
+		FinishReason: r.finishReason,
+		Usage:        (*usageJSON)(&r.usage),
+	})
+}
+
+func (r *ModelCallCompletedResult) UnmarshalJSON(data []byte) error {
+	var v modelCallResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.model = v.Model
+	r.responseID = v.ResponseID
+	r.finishReason = v.FinishReason
+	r.usage = Usage{}
+	if v.Usage != nil {
+		r.usage = Usage(*v.Usage)
+	}
+	return nil
+}
+
+type runCompletedResultJSON struct {
+	Type         string       `json:"type"`
+	FinishReason FinishReason `json:"finish_reason"`
+}
+
+func (r *RunCompletedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(runCompletedResultJSON{Type: r.Type(), FinishReason: r.finishReason})
+}
+
+func (r *RunCompletedResult) UnmarshalJSON(data []byte) error {
+	var v runCompletedResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.finishReason = v.FinishReason
+	return nil
+}
+
+type retryResultJSON struct {
+	Type        string `json:"type"`
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts"`
+	DelayMs     int64  `json:"delay_ms"`
+	Error       string `json:"error"`
+}
+
+func (r *RetryResult) MarshalJSON() ([]byte, error) {
+	msg := ""
+	if r.err != nil {
+		msg = r.err.Error()
+	}
+	return json.Marshal(retryResultJSON{
+		Type:        r.Type(),
+		Attempt:     r.attempt,
+		MaxAttempts: r.maxAttempts,
+		DelayMs:     r.delay.Milliseconds(),
+		Error:       msg,
+	})
+}
+
+func (r *RetryResult) UnmarshalJSON(data []byte) error {
+	var v retryResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.attempt = v.Attempt
+	r.maxAttempts = v.MaxAttempts
+	r.delay = time.Duration(v.DelayMs) * time.Millisecond
+	r.err = errors.New(v.Error)
+	return nil
+}
+
+type providerSwitchedResultJSON struct {
+	Type  string `json:"type"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r *ProviderSwitchedResult) MarshalJSON() ([]byte, error) {
+	msg := ""
+	if r.err != nil {
+		msg = r.err.Error()
+	}
+	return json.Marshal(providerSwitchedResultJSON{Type: r.Type(), From: r.from, To: r.to, Error: msg})
+}
+
+func (r *ProviderSwitchedResult) UnmarshalJSON(data []byte) error {
+	var v providerSwitchedResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.from = v.From
+	r.to = v.To
+	r.err = nil
+	if v.Error != "" {
+		r.err = errors.New(v.Error)
+	}
+	return nil
+}
+
+type routeSelectedResultJSON struct {
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Provider string `json:"provider"`
+	Reason   string `json:"reason"`
+}
+
+func (r *RouteSelectedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(routeSelectedResultJSON{
+		Type:     r.Type(),
+		Target:   r.target,
+		Provider: r.provider,
+		Reason:   r.reason,
+	})
+}
+
+func (r *RouteSelectedResult) UnmarshalJSON(data []byte) error {
+	var v routeSelectedResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.target = v.Target
+	r.provider = v.Provider
+	r.reason = v.Reason
+	return nil
+}
+
+type handoffResultJSON struct {
+	Type   string `json:"type"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r *HandoffResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(handoffResultJSON{
+		Type:   r.Type(),
+		From:   r.from,
+		To:     r.to,
+		Reason: r.reason,
+	})
+}
+
+func (r *HandoffResult) UnmarshalJSON(data []byte) error {
+	var v handoffResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.from = v.From
+	r.to = v.To
+	r.reason = v.Reason
+	return nil
+}
+
+type planResultJSON struct {
+	Type  string     `json:"type"`
+	Steps []PlanStep `json:"steps"`
+}
+
+func (r *PlanResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(planResultJSON{
+		Type:  r.Type(),
+		Steps: r.steps,
+	})
+}
+
+func (r *PlanResult) UnmarshalJSON(data []byte) error {
+	var v planResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.steps = v.Steps
+	return nil
+}
+
+type planStepResultJSON struct {
+	Type  string   `json:"type"`
+	Index int      `json:"index"`
+	Step  PlanStep `json:"step"`
+}
+
+func (r *PlanStepResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(planStepResultJSON{
+		Type:  r.Type(),
+		Index: r.index,
+		Step:  r.step,
+	})
+}
+
+func (r *PlanStepResult) UnmarshalJSON(data []byte) error {
+	var v planStepResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.index = v.Index
+	r.step = v.Step
+	return nil
+}
+
+type critiqueResultJSON struct {
+	Type     string `json:"type"`
+	Round    int    `json:"round"`
+	Approved bool   `json:"approved"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+func (r *CritiqueResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(critiqueResultJSON{
+		Type:     r.Type(),
+		Round:    r.round,
+		Approved: r.approved,
+		Feedback: r.feedback,
+	})
+}
+
+func (r *CritiqueResult) UnmarshalJSON(data []byte) error {
+	var v critiqueResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.round = v.Round
+	r.approved = v.Approved
+	r.feedback = v.Feedback
+	return nil
+}
+
+type citationsResultJSON struct {
+	Type      string     `json:"type"`
+	Query     string     `json:"query"`
+	Citations []Citation `json:"citations"`
+}
+
+func (r *CitationsResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(citationsResultJSON{
+		Type:      r.Type(),
+		Query:     r.query,
+		Citations: r.citations,
+	})
+}
+
+func (r *CitationsResult) UnmarshalJSON(data []byte) error {
+	var v citationsResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	r.query = v.Query
+	r.citations = v.Citations
+	return nil
+}
+
+type subAgentResultJSON struct {
+	Type         string          `json:"type"`
+	ParentCallID string          `json:"parent_call_id"`
+	Agent        string          `json:"agent"`
+	Result       json.RawMessage `json:"result"`
+}
+
+func (r *SubAgentResult) MarshalJSON() ([]byte, error) {
+	result, err := json.Marshal(r.result)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(subAgentResultJSON{
+		Type:         r.Type(),
+		ParentCallID: r.parentCallID,
+		Agent:        r.agent,
+		Result:       result,
+	})
+}
+
+func (r *SubAgentResult) UnmarshalJSON(data []byte) error {
+	var v subAgentResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, r.Type()); err != nil {
+		return err
+	}
+	result, err := UnmarshalResult(v.Result)
+	if err != nil {
+		return err
+	}
+	r.parentCallID = v.ParentCallID
+	r.agent = v.Agent
+	r.result = result
+	return nil
+}
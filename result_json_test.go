@@ -0,0 +1,100 @@
+package orenoagent_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		result orenoagent.Result
+	}{
+		{"message", orenoagent.NewMessageResult("Hello")},
+		{"message_delta", orenoagent.NewMessageDeltaResult("Hel")},
+		{"reasoning", orenoagent.NewReasoningResult("Thinking")},
+		{"reasoning_delta", orenoagent.NewReasoningDeltaResult("Thin")},
+		{"function_call", orenoagent.NewFunctionCallResult("call_1", "clock", `{"zone":"UTC"}`)},
+		{"function_call_output", orenoagent.NewFunctionCallOutputResult("call_1", "clock", "12:00")},
+		{"error", orenoagent.NewErrorResult(errors.New("boom"))},
+		{"run_started", orenoagent.NewRunStartedResult("What time is it?")},
+		{"model_call_started", orenoagent.NewModelCallStartedResult("gpt-test")},
+		{"model_call_completed", orenoagent.NewModelCallCompletedResult("gpt-test", "resp_1", orenoagent.FinishReasonToolCalls, orenoagent.Usage{
+			InputTokens:     10,
+			OutputTokens:    5,
+			TotalTokens:     15,
+			CachedTokens:    2,
+			ReasoningTokens: 1,
+		})},
+		{"run_completed", orenoagent.NewRunCompletedResult(orenoagent.FinishReasonMaxTokens)},
+		{"retry", orenoagent.NewRetryResult(2, 5, 1500*time.Millisecond, errors.New("rate limited"))},
+		{"provider_switched", orenoagent.NewProviderSwitchedResult("openai:a", "gemini:b", errors.New("down"))},
+		{"route_selected", orenoagent.NewRouteSelectedResult("coding", "openai:a", "matched rule")},
+		{"handoff", orenoagent.NewHandoffResult("triage", "billing", "invoice question")},
+		{"plan", orenoagent.NewPlanResult([]orenoagent.PlanStep{
+			{Description: "Look it up", Status: orenoagent.StepDone, Output: "found"},
+			{Description: "Answer", Status: orenoagent.StepPending},
+		})},
+		{"plan_step", orenoagent.NewPlanStepResult(1, orenoagent.PlanStep{Description: "Answer", Status: orenoagent.StepFailed, Output: "no data"})},
+		{"critique", orenoagent.NewCritiqueResult(1, false, "Cite the source.")},
+		{"citations", orenoagent.NewCitationsResult("setup", []orenoagent.Citation{
+			{ID: "docs/setup.md#1", Source: "docs/setup.md", Title: "Setup", Text: "Run make.", Score: 0.75, Number: 3},
+		})},
+		{"sub_agent", orenoagent.NewSubAgentResult("call_2", "researcher", orenoagent.NewFunctionCallResult("call_9", "search", `{}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			got, err := orenoagent.UnmarshalResult(data)
+			if err != nil {
+				t.Fatalf("UnmarshalResult(%s): %v", data, err)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.result) {
+				t.Fatalf("type = %T, want %T", got, tt.result)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.result) {
+				t.Errorf("String() = %q, want %q", fmt.Sprint(got), fmt.Sprint(tt.result))
+			}
+			again, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal after round trip: %v", err)
+			}
+			if string(again) != string(data) {
+				t.Errorf("round trip changed JSON:\n got  %s\n want %s", again, data)
+			}
+		})
+	}
+}
+
+func TestUnmarshalResultErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown type", `{"type":"unknown"}`},
+		{"invalid JSON", `{"type":`},
+		{"unknown nested type", `{"type":"sub_agent","parent_call_id":"call_1","agent":"a","result":{"type":"unknown"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r, err := orenoagent.UnmarshalResult([]byte(tt.data)); err == nil {
+				t.Errorf("UnmarshalResult(%s) = %v, want an error", tt.data, r)
+			}
+		})
+	}
+
+	// A document of another type is rejected by the concrete type.
+	var m orenoagent.MessageResult
+	if err := json.Unmarshal([]byte(`{"type":"think","text":"x"}`), &m); err == nil {
+		t.Error("MessageResult accepted a think document")
+	}
+}
@@ -0,0 +1,177 @@
+package orenoagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Retriever finds passages relevant to a query, for example in a vector
+// index built with the rag package.
+type Retriever interface {
+	// Retrieve returns the passages for query, best first.
+	Retrieve(ctx context.Context, query string) ([]Citation, error)
+}
+
+// RetrievalOption configures retrieval.
+type RetrievalOption func(*retrieval)
+
+// WithSearchTool gives the model a search tool instead of adding the
+// retrieved passages to every question, so that the model decides when and
+// what to search.
+// Default: passages are added to every question
+func WithSearchTool(name, description string) RetrievalOption {
+	return func(r *retrieval) {
+		r.toolName = name
+		r.toolDescription = description
+	}
+}
+
+// WithRetrieval connects a Retriever to the agent. By default the passages
+// for each question are retrieved before it is sent to the model and added
+// to it as numbered sources. With WithSearchTool, the model searches through
+// a tool instead. Either way the passages are published as a
+// CitationsResult, and the model is asked to cite them as [1], [2] and so
+// on. Passages are numbered across the run, so later searches do not reuse
+// the numbers of earlier ones.
+//
+// Example usage:
+//
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithRetrieval(retriever))
+//	agent := orenoagent.NewAgent(provider,
+//		orenoagent.WithTools(tools),
+//		orenoagent.WithRetrieval(retriever, orenoagent.WithSearchTool("search_docs", "Searches the product documentation.")),
+//	)
+func WithRetrieval(retriever Retriever, opts ...RetrievalOption) AgentOption {
+	return func(a *Agent) {
+		r := &retrieval{retriever: retriever}
+		for _, opt := range opts {
+			opt(r)
+		}
+		a.retrieval = r
+	}
+}
+
+type retrieval struct {
+	retriever       Retriever
+	toolName        string
+	toolDescription string
+}
+
+const retrievalPrompt = `Answer the question using the sources below, and cite the sources you use by their number, such as [1]. If the sources do not contain the answer, say so.
+
+Sources:
+%s
+Question:
+%s`
+
+// augment retrieves passages for question, publishes them and returns the
+// question with the passages added. The question is returned unchanged if
+// nothing is found.
+func (r *retrieval) augment(ctx context.Context, publish func(Result) bool, question string) (string, error) {
+	citations, err := r.retriever.Retrieve(ctx, question)
+	if err != nil {
+		return "", fmt.Errorf("retrieval: %w", err)
+	}
+	if len(citations) == 0 {
+		return question, nil
+	}
+	numberCitations(ctx, citations)
+	if !publish(NewCitationsResult(question, citations)) {
+		return "", errors.New("cancelled")
+	}
+	return fmt.Sprintf(retrievalPrompt, formatCitations(citations), question), nil
+}
+
+// tool returns the search tool. Its citations are published into the run
+// that calls it.
+func (r *retrieval) tool() Tool {
+	return Tool{
+		Name:        r.toolName,
+		Description: r.toolDescription + " Cite the results you use by their number, such as [1].",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{
+					"type":        "string",
+					"description": "What to search for.",
+				},
+			},
+			"required": []string{"query"},
+		},
+		FunctionContext: func(ctx context.Context, arguments string) string {
+			var args struct {
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil || args.Query == "" {
+				return "error: query is required"
+			}
+			citations, err := r.retriever.Retrieve(ctx, args.Query)
+			if err != nil {
+				return fmt.Sprintf("error: %v", err)
+			}
+			if len(citations) == 0 {
+				return "No results."
+			}
+			numberCitations(ctx, citations)
+			if publish, ok := ctx.Value(publisherKey{}).(func(Result) bool); ok {
+				publish(NewCitationsResult(args.Query, citations))
+			}
+			return formatCitations(citations)
+		},
+	}
+}
+
+func formatCitations(citations []Citation) string {
+	var b strings.Builder
+	for _, c := range citations {
+		fmt.Fprintf(&b, "[%d] %s", c.Number, c.Source)
+		if c.Title != "" {
+			fmt.Fprintf(&b, " (%s)", c.Title)
+		}
+		fmt.Fprintf(&b, "\n%s\n\n", strings.TrimSpace(c.Text))
+	}
+	return b.String()
+}
+
+// citationNumbersKey is the context key of the citationNumbers of the
+// current run.
+type citationNumbersKey struct{}
+
+// citationNumbers hands out the citation numbers of a run. Search tools may
+// run concurrently.
+type citationNumbers struct {
+	mu   sync.Mutex
+	byID map[string]int
+	last int
+}
+
+// numberCitations sets the Number of each citation. A passage already cited
+// in the run keeps its number and new passages get the next free ones.
+// Outside a run, the citations are numbered from 1.
+func numberCitations(ctx context.Context, citations []Citation) {
+	n, ok := ctx.Value(citationNumbersKey{}).(*citationNumbers)
+	if !ok {
+		n = &citationNumbers{}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.byID == nil {
+		n.byID = map[string]int{}
+	}
+	for i, c := range citations {
+		if number, ok := n.byID[c.ID]; ok && c.ID != "" {
+			citations[i].Number = number
+			continue
+		}
+		n.last++
+		citations[i].Number = n.last
+		if c.ID != "" {
+			n.byID[c.ID] = n.last
+		}
+	}
+}
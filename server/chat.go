@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/internal/sse"
+)
+
+type chatCompletionRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Stream bool `json:"stream"`
+}
+
+type chatCompletionObject struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+}
+
+type chatCompletionChoice struct {
+	Index        int                  `json:"index"`
+	Delta        *chatCompletionDelta `json:"delta,omitempty"`
+	Message      *chatCompletionDelta `json:"message,omitempty"`
+	FinishReason *string              `json:"finish_reason"`
+}
+
+type chatCompletionDelta struct {
+	Role             string  `json:"role,omitempty"`
+	Content          *string `json:"content,omitempty"`
+	ReasoningContent *string `json:"reasoning_content,omitempty"`
+}
+
+// chatFinishReason maps an agent finish reason to a Chat Completions one.
+func chatFinishReason(reason orenoagent.FinishReason) string {
+	switch reason {
+	case orenoagent.FinishReasonMaxTokens:
+		return "length"
+	case orenoagent.FinishReasonContentFilter:
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	if !decodeBody(w, r, s.maxBodySize, &req) {
+		return
+	}
+
+	turns := make([]turn, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		text, err := contentText(m.Content)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		turns = append(turns, turn{role: m.Role, text: text})
+	}
+	question, err := buildQuestion(turns)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	subscriber, err := s.newAgent().Ask(r.Context(), question)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	c := &chatCompletion{
+		id:      newID("chatcmpl-"),
+		created: time.Now().Unix(),
+		model:   s.model,
+	}
+
+	if !req.Stream {
+		outcome := drain(subscriber, c)
+		if outcome.err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", outcome.err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, c.completion(outcome.finishReason))
+		return
+	}
+
+	stream, ok := sse.NewWriter(w)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "server_error", "streaming is not supported")
+		return
+	}
+	c.sse = stream
+	c.writeChunk(&chatCompletionDelta{Role: "assistant"}, nil)
+
+	outcome := drain(subscriber, c)
+	if outcome.err != nil {
+		stream.Write("", map[string]any{
+			"error": map[string]any{
+				"message": outcome.err.Error(),
+				"type":    "server_error",
+			},
+		})
+		return
+	}
+	reason := chatFinishReason(outcome.finishReason)
+	c.writeChunk(&chatCompletionDelta{}, &reason)
+	stream.WriteData("", "[DONE]")
+}
+
+// chatCompletion builds a Chat Completions response, either streamed as
+// chunks or collected into a single object.
+type chatCompletion struct {
+	id      string
+	created int64
+	model   string
+
+	// sse is nil for non-streaming requests.
+	sse *sse.Writer
+
+	messages  []string
+	reasoning []string
+}
+
+func (c *chatCompletion) writeChunk(delta *chatCompletionDelta, finishReason *string) {
+	c.sse.Write("", chatCompletionObject{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []chatCompletionChoice{
+			{Index: 0, Delta: delta, FinishReason: finishReason},
+		},
+	})
+}
+
+func (c *chatCompletion) completion(reason orenoagent.FinishReason) chatCompletionObject {
+	content := strings.Join(c.messages, "\n\n")
+	message := &chatCompletionDelta{Role: "assistant", Content: &content}
+	if len(c.reasoning) > 0 {
+		reasoning := strings.Join(c.reasoning, "\n\n")
+		message.ReasoningContent = &reasoning
+	}
+	finishReason := chatFinishReason(reason)
+	return chatCompletionObject{
+		ID:      c.id,
+		Object:  "chat.completion",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []chatCompletionChoice{
+			{Index: 0, Message: message, FinishReason: &finishReason},
+		},
+	}
+}
+
+func (c *chatCompletion) messageStart() {
+	// Separate the texts of successive messages, e.g. before and after a
+	// tool call.
+	if c.sse != nil && len(c.messages) > 0 {
+		sep := "\n\n"
+		c.writeChunk(&chatCompletionDelta{Content: &sep}, nil)
+	}
+}
+
+func (c *chatCompletion) messageDelta(delta string) {
+	if c.sse != nil && delta != "" {
+		c.writeChunk(&chatCompletionDelta{Content: &delta}, nil)
+	}
+}
+
+func (c *chatCompletion) messageDone(text string) {
+	c.messages = append(c.messages, text)
+}
+
+func (c *chatCompletion) reasoningStart() {}
+
+func (c *chatCompletion) reasoningDelta(delta string) {
+	if c.sse != nil && delta != "" {
+		c.writeChunk(&chatCompletionDelta{ReasoningContent: &delta}, nil)
+	}
+}
+
+func (c *chatCompletion) reasoningDone(text string) {
+	c.reasoning = append(c.reasoning, text)
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/internal/sse"
+)
+
+type responseRequest struct {
+	Model              string          `json:"model"`
+	Input              json.RawMessage `json:"input"`
+	Instructions       string          `json:"instructions"`
+	PreviousResponseID string          `json:"previous_response_id"`
+	Stream             bool            `json:"stream"`
+}
+
+type responseObject struct {
+	ID                string            `json:"id"`
+	Object            string            `json:"object"`
+	CreatedAt         int64             `json:"created_at"`
+	Status            string            `json:"status"`
+	Model             string            `json:"model"`
+	Output            []*responseItem   `json:"output"`
+	PreviousID        *string           `json:"previous_response_id"`
+	IncompleteDetails map[string]string `json:"incomplete_details"`
+	Error             map[string]string `json:"error"`
+}
+
+type responseItem struct {
+	Type    string                `json:"type"`
+	ID      string                `json:"id"`
+	Status  string                `json:"status,omitempty"`
+	Role    string                `json:"role,omitempty"`
+	Content []responseContentPart `json:"content,omitempty"`
+	Summary []responseContentPart `json:"summary,omitempty"`
+}
+
+type responseContentPart struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	Annotations []any  `json:"annotations,omitempty"`
+}
+
+// parseResponseInput converts the input of a Responses request, either a
+// string or a list of input items, into conversation turns.
+func parseResponseInput(raw json.RawMessage) ([]turn, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []turn{{role: "user", text: text}}, nil
+	}
+	var items []struct {
+		Type    string          `json:"type"`
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	turns := make([]turn, 0, len(items))
+	for _, item := range items {
+		if item.Type != "" && item.Type != "message" {
+			return nil, fmt.Errorf("unsupported input item type: %q", item.Type)
+		}
+		text, err := contentText(item.Content)
+		if err != nil {
+			return nil, err
+		}
+		turns = append(turns, turn{role: item.Role, text: text})
+	}
+	return turns, nil
+}
+
+func (s *Server) handleResponses(w http.ResponseWriter, r *http.Request) {
+	var req responseRequest
+	if !decodeBody(w, r, s.maxBodySize, &req) {
+		return
+	}
+
+	turns, err := parseResponseInput(req.Input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if req.Instructions != "" {
+		turns = append([]turn{{role: "system", text: req.Instructions}}, turns...)
+	}
+	question, err := buildQuestion(turns)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var agent *orenoagent.Agent
+	if req.PreviousResponseID != "" {
+		var ok bool
+		agent, ok = s.takeAgent(req.PreviousResponseID)
+		if !ok {
+			writeError(w, http.StatusNotFound, "invalid_request_error",
+				fmt.Sprintf("previous response not found: %s", req.PreviousResponseID))
+			return
+		}
+	} else {
+		agent = s.newAgent()
+	}
+
+	subscriber, err := agent.Ask(r.Context(), question)
+	if err != nil {
+		if req.PreviousResponseID != "" {
+			s.storeAgent(req.PreviousResponseID, agent)
+		}
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	b := &responseBuilder{
+		resp: &responseObject{
+			ID:        newID("resp_"),
+			Object:    "response",
+			CreatedAt: time.Now().Unix(),
+			Status:    "in_progress",
+			Model:     s.model,
+			Output:    []*responseItem{},
+		},
+	}
+	if req.PreviousResponseID != "" {
+		b.resp.PreviousID = &req.PreviousResponseID
+	}
+
+	if req.Stream {
+		stream, ok := sse.NewWriter(w)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "server_error", "streaming is not supported")
+			return
+		}
+		b.sse = stream
+		b.event("response.created", map[string]any{"response": b.resp})
+		b.event("response.in_progress", map[string]any{"response": b.resp})
+	}
+
+	outcome := drain(subscriber, b)
+	b.finish(outcome)
+	switch {
+	case outcome.err == nil:
+		s.storeAgent(b.resp.ID, agent)
+	case req.PreviousResponseID != "":
+		// Let the client retry from the response it continued.
+		s.storeAgent(req.PreviousResponseID, agent)
+	}
+
+	if b.sse == nil {
+		if outcome.err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", outcome.err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, b.resp)
+	}
+}
+
+// responseBuilder builds a Responses API response, streaming its events when
+// sse is set.
+type responseBuilder struct {
+	resp     *responseObject
+	sse      *sse.Writer
+	sequence int
+
+	// current is the output item being streamed.
+	current *responseItem
+}
+
+func (b *responseBuilder) event(eventType string, fields map[string]any) {
+	if b.sse == nil {
+		return
+	}
+	fields["type"] = eventType
+	fields["sequence_number"] = b.sequence
+	b.sequence++
+	b.sse.Write(eventType, fields)
+}
+
+func (b *responseBuilder) addItem(item *responseItem) int {
+	b.resp.Output = append(b.resp.Output, item)
+	b.current = item
+	outputIndex := len(b.resp.Output) - 1
+	b.event("response.output_item.added", map[string]any{
+		"output_index": outputIndex,
+		"item":         item,
+	})
+	return outputIndex
+}
+
+func (b *responseBuilder) messageStart() {
+	b.addItem(&responseItem{
+		Type:    "message",
+		ID:      newID("msg_"),
+		Status:  "in_progress",
+		Role:    "assistant",
+		Content: []responseContentPart{},
+	})
+	b.event("response.content_part.added", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  len(b.resp.Output) - 1,
+		"content_index": 0,
+		"part":          responseContentPart{Type: "output_text", Text: "", Annotations: []any{}},
+	})
+}
+
+func (b *responseBuilder) messageDelta(delta string) {
+	if delta == "" {
+		return
+	}
+	b.event("response.output_text.delta", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  len(b.resp.Output) - 1,
+		"content_index": 0,
+		"delta":         delta,
+		"logprobs":      []any{},
+	})
+}
+
+func (b *responseBuilder) messageDone(text string) {
+	outputIndex := len(b.resp.Output) - 1
+	part := responseContentPart{Type: "output_text", Text: text, Annotations: []any{}}
+	b.event("response.output_text.done", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  outputIndex,
+		"content_index": 0,
+		"text":          text,
+		"logprobs":      []any{},
+	})
+	b.event("response.content_part.done", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  outputIndex,
+		"content_index": 0,
+		"part":          part,
+	})
+	b.current.Status = "completed"
+	b.current.Content = []responseContentPart{part}
+	b.event("response.output_item.done", map[string]any{
+		"output_index": outputIndex,
+		"item":         b.current,
+	})
+}
+
+func (b *responseBuilder) reasoningStart() {
+	b.addItem(&responseItem{
+		Type:    "reasoning",
+		ID:      newID("rs_"),
+		Summary: []responseContentPart{},
+	})
+	b.event("response.reasoning_summary_part.added", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  len(b.resp.Output) - 1,
+		"summary_index": 0,
+		"part":          responseContentPart{Type: "summary_text", Text: ""},
+	})
+}
+
+func (b *responseBuilder) reasoningDelta(delta string) {
+	if delta == "" {
+		return
+	}
+	b.event("response.reasoning_summary_text.delta", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  len(b.resp.Output) - 1,
+		"summary_index": 0,
+		"delta":         delta,
+	})
+}
+
+func (b *responseBuilder) reasoningDone(text string) {
+	outputIndex := len(b.resp.Output) - 1
+	part := responseContentPart{Type: "summary_text", Text: text}
+	b.event("response.reasoning_summary_text.done", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  outputIndex,
+		"summary_index": 0,
+		"text":          text,
+	})
+	b.event("response.reasoning_summary_part.done", map[string]any{
+		"item_id":       b.current.ID,
+		"output_index":  outputIndex,
+		"summary_index": 0,
+		"part":          part,
+	})
+	b.current.Summary = []responseContentPart{part}
+	b.event("response.output_item.done", map[string]any{
+		"output_index": outputIndex,
+		"item":         b.current,
+	})
+}
+
+// finish sets the final status of the response and sends the terminal event.
+func (b *responseBuilder) finish(outcome runOutcome) {
+	switch {
+	case outcome.err != nil:
+		b.resp.Status = "failed"
+		b.resp.Error = map[string]string{
+			"code":    "server_error",
+			"message": outcome.err.Error(),
+		}
+		b.event("response.failed", map[string]any{"response": b.resp})
+	case outcome.finishReason == orenoagent.FinishReasonMaxTokens:
+		b.resp.Status = "incomplete"
+		b.resp.IncompleteDetails = map[string]string{"reason": "max_output_tokens"}
+		b.event("response.incomplete", map[string]any{"response": b.resp})
+	case outcome.finishReason == orenoagent.FinishReasonContentFilter:
+		b.resp.Status = "incomplete"
+		b.resp.IncompleteDetails = map[string]string{"reason": "content_filter"}
+		b.event("response.incomplete", map[string]any{"response": b.resp})
+	default:
+		b.resp.Status = "completed"
+		b.event("response.completed", map[string]any{"response": b.resp})
+	}
+}
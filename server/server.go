@@ -0,0 +1,322 @@
+// Package server exposes an orenoagent.Agent through the OpenAI Chat
+// Completions and Responses HTTP APIs, so that existing OpenAI clients can
+// talk to an agent, including its server-side tools, as if it were a model.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/util"
+)
+
+// Server is an http.Handler serving
+//
+//	POST /v1/chat/completions
+//	POST /v1/responses
+//	GET  /v1/models
+//
+// Tool calls are executed by the agent and are not exposed to the client.
+type Server struct {
+	newAgent func() *orenoagent.Agent
+	mux      *http.ServeMux
+
+	// Model name reported to clients.
+	model string
+
+	// Maximum size of the request body in bytes.
+	maxBodySize int64
+
+	mu sync.Mutex
+	// Agents keyed by response ID, used to continue a conversation with
+	// previous_response_id.
+	responses map[string]*storedAgent
+	// Stored responses are discarded after this duration.
+	responseTTL time.Duration
+}
+
+type storedAgent struct {
+	agent   *orenoagent.Agent
+	created time.Time
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithModelName sets the model name reported to clients.
+// Default: "orenoagent"
+func WithModelName(model string) ServerOption {
+	return func(s *Server) {
+		s.model = model
+	}
+}
+
+// WithMaxBodySize sets the maximum accepted request body size in bytes.
+// Default: 4 MiB.
+func WithMaxBodySize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxBodySize = size
+	}
+}
+
+// WithResponseTTL sets how long a response can be continued with
+// previous_response_id. Default: 1 hour.
+func WithResponseTTL(ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.responseTTL = ttl
+	}
+}
+
+// NewServer creates a new Server. newAgent is called for every new
+// conversation.
+//
+// Example usage:
+//
+//	srv := server.NewServer(func() *orenoagent.Agent {
+//		return orenoagent.NewAgent(openai.NewProvider(client), orenoagent.WithTools(tools))
+//	})
+//	http.ListenAndServe(":8080", srv)
+//
+// Any OpenAI client can then use it by setting its base URL to
+// http://localhost:8080/v1/.
+func NewServer(newAgent func() *orenoagent.Agent, opts ...ServerOption) *Server {
+	s := &Server{
+		newAgent:    newAgent,
+		model:       "orenoagent",
+		maxBodySize: 4 << 20,
+		responses:   map[string]*storedAgent{},
+		responseTTL: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("POST /v1/responses", s.handleResponses)
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"data": []map[string]any{
+			{
+				"id":       s.model,
+				"object":   "model",
+				"created":  0,
+				"owned_by": "orenoagent",
+			},
+		},
+	})
+}
+
+// storeAgent remembers agent under responseID so that it can be continued.
+func (s *Server) storeAgent(responseID string, agent *orenoagent.Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range s.responses {
+		if now.Sub(stored.created) > s.responseTTL {
+			delete(s.responses, id)
+		}
+	}
+	s.responses[responseID] = &storedAgent{agent: agent, created: now}
+}
+
+// takeAgent removes and returns the agent stored under responseID.
+// An agent can only be continued once, because its history moves forward.
+func (s *Server) takeAgent(responseID string) (*orenoagent.Agent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.responses[responseID]
+	if !ok || time.Since(stored.created) > s.responseTTL {
+		return nil, false
+	}
+	delete(s.responses, responseID)
+	return stored.agent, true
+}
+
+// turn is one message of a conversation sent by the client.
+type turn struct {
+	role string
+	text string
+}
+
+// buildQuestion turns the client's messages into a single question. The
+// agent keeps its own history, so earlier turns sent by a stateless client
+// are rendered as context in front of the last user message.
+func buildQuestion(turns []turn) (string, error) {
+	last := -1
+	for i, t := range turns {
+		if t.role == "user" {
+			last = i
+		}
+	}
+	if last < 0 {
+		return "", fmt.Errorf("no user message")
+	}
+	if last == 0 {
+		return turns[0].text, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Conversation so far:\n\n")
+	for _, t := range turns[:last] {
+		fmt.Fprintf(&b, "[%s]\n%s\n\n", t.role, t.text)
+	}
+	b.WriteString("Reply to the following message:\n\n")
+	b.WriteString(turns[last].text)
+	for _, t := range turns[last+1:] {
+		fmt.Fprintf(&b, "\n\n[%s]\n%s", t.role, t.text)
+	}
+	return b.String(), nil
+}
+
+// contentText extracts the text of a message content, which is either a
+// string or an array of content parts.
+func contentText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("invalid content: %w", err)
+	}
+	var texts []string
+	for _, p := range parts {
+		switch p.Type {
+		case "text", "input_text", "output_text":
+			texts = append(texts, p.Text)
+		default:
+			return "", fmt.Errorf("unsupported content part type: %q", p.Type)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// streamHandler receives the text parts of an agent run.
+type streamHandler interface {
+	messageStart()
+	messageDelta(delta string)
+	messageDone(text string)
+	reasoningStart()
+	reasoningDelta(delta string)
+	reasoningDone(text string)
+}
+
+// runOutcome summarises how an agent run ended.
+type runOutcome struct {
+	finishReason orenoagent.FinishReason
+	err          error
+}
+
+// drain reads all results of an Ask and forwards message and reasoning text
+// to h. Providers send a complete MessageResult or ReasoningResult after the
+// matching delta stream, so those are only forwarded when no delta preceded
+// them.
+func drain(subscriber *util.Subscriber[orenoagent.Result], h streamHandler) runOutcome {
+	outcome := runOutcome{finishReason: orenoagent.FinishReasonStop}
+	pendingMessages := 0
+	pendingReasonings := 0
+
+	for result := range subscriber.Subscribe() {
+		switch r := result.(type) {
+		case *orenoagent.MessageDeltaResult:
+			h.messageStart()
+			var text strings.Builder
+			for delta := range r.Subscribe() {
+				text.WriteString(delta)
+				h.messageDelta(delta)
+			}
+			h.messageDone(text.String())
+			pendingMessages++
+		case *orenoagent.MessageResult:
+			if pendingMessages > 0 {
+				pendingMessages--
+				continue
+			}
+			h.messageStart()
+			h.messageDelta(r.String())
+			h.messageDone(r.String())
+		case *orenoagent.ReasoningDeltaResult:
+			h.reasoningStart()
+			var text strings.Builder
+			for delta := range r.Subscribe() {
+				text.WriteString(delta)
+				h.reasoningDelta(delta)
+			}
+			h.reasoningDone(text.String())
+			pendingReasonings++
+		case *orenoagent.ReasoningResult:
+			if pendingReasonings > 0 {
+				pendingReasonings--
+				continue
+			}
+			h.reasoningStart()
+			h.reasoningDelta(r.String())
+			h.reasoningDone(r.String())
+		case *orenoagent.ErrorResult:
+			outcome.err = r.Error()
+		case *orenoagent.RunCompletedResult:
+			outcome.finishReason = r.FinishReason()
+		}
+	}
+	return outcome
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the OpenAI error format.
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    errType,
+			"param":   nil,
+			"code":    nil,
+		},
+	})
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, maxBodySize int64, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,247 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+	"github.com/demouth/orenoagent-go/server"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/responses"
+)
+
+// fakeProvider streams its reasoning and answer as deltas and then sends
+// the complete texts, the way the real providers do. The question "fail"
+// fails.
+type fakeProvider struct {
+	questions []string
+}
+
+func (p *fakeProvider) SetTools([]provider.Tool) {}
+
+func (p *fakeProvider) ProcessMessage(_ context.Context, yield func(provider.Result) bool, question string) error {
+	p.questions = append(p.questions, question)
+	if question == "fail" {
+		return errors.New("model unavailable")
+	}
+
+	reasoning := provider.NewReasoningDeltaResult("Think")
+	yield(reasoning)
+	reasoning.AddDelta("ing.")
+	reasoning.Close()
+	yield(provider.NewReasoningResult("Thinking."))
+
+	message := provider.NewMessageDeltaResult("Hello")
+	yield(message)
+	message.AddDelta(", world")
+	message.Close()
+	yield(provider.NewMessageResult("Hello, world"))
+	return nil
+}
+
+func newTestServer(t *testing.T, prov *fakeProvider) openai.Client {
+	t.Helper()
+	srv := httptest.NewServer(server.NewServer(func() *orenoagent.Agent {
+		return orenoagent.NewAgent(prov)
+	}, server.WithModelName("test-agent")))
+	t.Cleanup(srv.Close)
+	return openai.NewClient(option.WithBaseURL(srv.URL+"/v1/"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+}
+
+func TestChatCompletions(t *testing.T) {
+	prov := &fakeProvider{}
+	client := newTestServer(t, prov)
+
+	completion, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Model:    "test-agent",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := completion.Choices[0].Message.Content; got != "Hello, world" {
+		t.Errorf("content = %q, want %q", got, "Hello, world")
+	}
+	if got := completion.Choices[0].FinishReason; got != "stop" {
+		t.Errorf("finish_reason = %q, want stop", got)
+	}
+	if completion.Model != "test-agent" {
+		t.Errorf("model = %q, want test-agent", completion.Model)
+	}
+	if len(prov.questions) != 1 || prov.questions[0] != "Hi" {
+		t.Errorf("questions = %q, want [Hi]", prov.questions)
+	}
+}
+
+func TestChatCompletionsStreaming(t *testing.T) {
+	client := newTestServer(t, &fakeProvider{})
+
+	stream := client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+		Model:    "test-agent",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hi")},
+	})
+	var content, reasoning strings.Builder
+	var finishReason string
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if field, ok := chunk.Choices[0].Delta.JSON.ExtraFields["reasoning_content"]; ok && field.Raw() != "null" {
+			reasoning.WriteString(strings.Trim(field.Raw(), `"`))
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := content.String(); got != "Hello, world" {
+		t.Errorf("streamed content = %q, want %q", got, "Hello, world")
+	}
+	if got := reasoning.String(); got != "Thinking." {
+		t.Errorf("streamed reasoning = %q, want %q", got, "Thinking.")
+	}
+	if finishReason != "stop" {
+		t.Errorf("finish_reason = %q, want stop", finishReason)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	prov := &fakeProvider{}
+	client := newTestServer(t, prov)
+	ctx := context.Background()
+
+	resp, err := client.Responses.New(ctx, responses.ResponseNewParams{
+		Model: "test-agent",
+		Input: responses.ResponseNewParamsInputUnion{OfString: openai.String("Hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != responses.ResponseStatusCompleted {
+		t.Errorf("status = %q, want completed", resp.Status)
+	}
+	if got := resp.OutputText(); got != "Hello, world" {
+		t.Errorf("output text = %q, want %q", got, "Hello, world")
+	}
+	var types []string
+	for _, item := range resp.Output {
+		types = append(types, item.Type)
+	}
+	if got := strings.Join(types, ","); got != "reasoning,message" {
+		t.Errorf("output items = %s, want reasoning,message", got)
+	}
+
+	// The stored agent continues the conversation.
+	next, err := client.Responses.New(ctx, responses.ResponseNewParams{
+		Model:              "test-agent",
+		Input:              responses.ResponseNewParamsInputUnion{OfString: openai.String("Again")},
+		PreviousResponseID: openai.String(resp.ID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.PreviousResponseID != resp.ID {
+		t.Errorf("previous_response_id = %q, want %q", next.PreviousResponseID, resp.ID)
+	}
+	if got := strings.Join(prov.questions, ","); got != "Hi,Again" {
+		t.Errorf("questions = %s, want Hi,Again", got)
+	}
+
+	// A response can only be continued once.
+	_, err = client.Responses.New(ctx, responses.ResponseNewParams{
+		Model:              "test-agent",
+		Input:              responses.ResponseNewParamsInputUnion{OfString: openai.String("Again")},
+		PreviousResponseID: openai.String(resp.ID),
+	})
+	if apiErr, ok := err.(*openai.Error); !ok || apiErr.StatusCode != 404 {
+		t.Errorf("continuing twice: err = %v, want 404", err)
+	}
+}
+
+func TestResponsesContinueAfterError(t *testing.T) {
+	prov := &fakeProvider{}
+	client := newTestServer(t, prov)
+	ctx := context.Background()
+
+	resp, err := client.Responses.New(ctx, responses.ResponseNewParams{
+		Model: "test-agent",
+		Input: responses.ResponseNewParamsInputUnion{OfString: openai.String("Hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Responses.New(ctx, responses.ResponseNewParams{
+		Model:              "test-agent",
+		Input:              responses.ResponseNewParamsInputUnion{OfString: openai.String("fail")},
+		PreviousResponseID: openai.String(resp.ID),
+	})
+	if apiErr, ok := err.(*openai.Error); !ok || apiErr.StatusCode != 500 {
+		t.Fatalf("failed run: err = %v, want 500", err)
+	}
+
+	// The failed run does not lose the conversation.
+	if _, err := client.Responses.New(ctx, responses.ResponseNewParams{
+		Model:              "test-agent",
+		Input:              responses.ResponseNewParamsInputUnion{OfString: openai.String("Again")},
+		PreviousResponseID: openai.String(resp.ID),
+	}); err != nil {
+		t.Fatalf("retry after failed run: %v", err)
+	}
+	if got := strings.Join(prov.questions, ","); got != "Hi,fail,Again" {
+		t.Errorf("questions = %s, want Hi,fail,Again", got)
+	}
+}
+
+func TestResponsesStreaming(t *testing.T) {
+	client := newTestServer(t, &fakeProvider{})
+
+	stream := client.Responses.NewStreaming(context.Background(), responses.ResponseNewParams{
+		Model: "test-agent",
+		Input: responses.ResponseNewParamsInputUnion{OfString: openai.String("Hi")},
+	})
+	var text, reasoning strings.Builder
+	var completed *responses.Response
+	var sequence []int64
+	for stream.Next() {
+		event := stream.Current()
+		sequence = append(sequence, event.SequenceNumber)
+		switch event.Type {
+		case "response.output_text.delta":
+			text.WriteString(event.Delta)
+		case "response.reasoning_summary_text.delta":
+			reasoning.WriteString(event.Delta)
+		case "response.completed":
+			completed = &event.Response
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := text.String(); got != "Hello, world" {
+		t.Errorf("streamed text = %q, want %q", got, "Hello, world")
+	}
+	if got := reasoning.String(); got != "Thinking." {
+		t.Errorf("streamed reasoning = %q, want %q", got, "Thinking.")
+	}
+	if completed == nil {
+		t.Fatal("no response.completed event")
+	}
+	if got := completed.OutputText(); got != "Hello, world" {
+		t.Errorf("completed output text = %q, want %q", got, "Hello, world")
+	}
+	for i, n := range sequence {
+		if n != int64(i) {
+			t.Fatalf("sequence numbers = %v, want 0, 1, 2, ...", sequence)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package orenoagent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/demouth/orenoagent-go/provider"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/demouth/orenoagent-go"
+
+// WithTracerProvider records OpenTelemetry spans for every Ask, every
+// request to the model and every tool call, with attributes following the
+// GenAI semantic conventions. Model and tool spans need a provider that
+// implements provider.HooksProvider.
+//
+// Example usage:
+//
+//	agent := orenoagent.NewAgent(provider, orenoagent.WithTracerProvider(otel.GetTracerProvider()))
+func WithTracerProvider(tp trace.TracerProvider) AgentOption {
+	return func(a *Agent) {
+		a.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithTraceContent records prompts, answers, tool arguments and tool outputs
+// on the spans. They may contain sensitive data, so they are not recorded by
+// default.
+func WithTraceContent(capture bool) AgentOption {
+	return func(a *Agent) {
+		a.traceContent = capture
+	}
+}
+
+// startRun starts the span of an Ask. The returned function ends it.
+func (a *Agent) startRun(ctx context.Context, question string) (context.Context, func(FinishReason, string, error)) {
+	if a.tracer == nil {
+		return ctx, func(FinishReason, string, error) {}
+	}
+
+	ctx, span := a.tracer.Start(ctx, "invoke_agent", trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "invoke_agent"),
+		attribute.String("gen_ai.system", genAISystem(a.prov)),
+	))
+	if a.traceContent {
+		span.SetAttributes(attribute.String("gen_ai.input.messages", messagesJSON([]provider.Message{
+			{Role: provider.RoleUser, Text: question},
+		})))
+	}
+
+	return ctx, func(finishReason FinishReason, answer string, err error) {
+		span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{string(finishReason)}))
+		if a.traceContent {
+			span.SetAttributes(attribute.String("gen_ai.output.messages", messagesJSON([]provider.Message{
+				{Role: provider.RoleAssistant, Text: answer},
+			})))
+		}
+		endSpan(span, err)
+	}
+}
+
+// tracingHooks returns hooks that record spans for model and tool calls.
+func (a *Agent) tracingHooks() Hooks {
+	return Hooks{
+		BeforeModelCall: func(ctx context.Context, call ModelCall) (context.Context, error) {
+			ctx, span := a.tracer.Start(ctx, "chat "+call.Model,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("gen_ai.operation.name", "chat"),
+					attribute.String("gen_ai.system", genAISystem(a.prov)),
+					attribute.String("gen_ai.request.model", call.Model),
+				),
+			)
+			if a.traceContent {
+				span.SetAttributes(attribute.String("gen_ai.input.messages", messagesJSON(call.Input)))
+			}
+			return ctx, nil
+		},
+		AfterModelCall: func(ctx context.Context, call ModelCall, completed *ModelCallCompletedResult, err error) {
+			span := trace.SpanFromContext(ctx)
+			if completed != nil {
+				usage := completed.Usage()
+				span.SetAttributes(
+					attribute.String("gen_ai.response.model", completed.Model()),
+					attribute.String("gen_ai.response.id", completed.ResponseID()),
+					attribute.StringSlice("gen_ai.response.finish_reasons", []string{string(completed.FinishReason())}),
+					attribute.Int("gen_ai.usage.input_tokens", usage.InputTokens),
+					attribute.Int("gen_ai.usage.output_tokens", usage.OutputTokens),
+				)
+			}
+			endSpan(span, err)
+		},
+		BeforeToolCall: func(ctx context.Context, call *ToolCall) (context.Context, error) {
+			ctx, span := a.tracer.Start(ctx, "execute_tool "+call.Name,
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(
+					attribute.String("gen_ai.operation.name", "execute_tool"),
+					attribute.String("gen_ai.tool.name", call.Name),
+					attribute.String("gen_ai.tool.call.id", call.CallID),
+				),
+			)
+			if a.traceContent {
+				span.SetAttributes(attribute.String("gen_ai.tool.call.arguments", call.Arguments))
+			}
+			return ctx, nil
+		},
+		AfterToolCall: func(ctx context.Context, call ToolCall, output string) string {
+			span := trace.SpanFromContext(ctx)
+			if a.traceContent {
+				span.SetAttributes(attribute.String("gen_ai.tool.call.result", output))
+			}
+			span.End()
+			return output
+		},
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// genAISystem returns the gen_ai.system value for a provider, based on the
+// prefix of its name such as "openai:gpt-5-nano".
+func genAISystem(p provider.Provider) string {
+	system, _, _ := strings.Cut(provider.NameOf(p), ":")
+	if system == "gemini" {
+		return "gcp.gemini"
+	}
+	return system
+}
+
+// messagesJSON encodes messages in the GenAI semantic conventions format.
+func messagesJSON(messages []provider.Message) string {
+	type part struct {
+		Type      string `json:"type"`
+		Content   string `json:"content,omitempty"`
+		ID        string `json:"id,omitempty"`
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+		Response  string `json:"response,omitempty"`
+	}
+	type message struct {
+		Role  string `json:"role"`
+		Parts []part `json:"parts"`
+	}
+
+	var out []message
+	for _, m := range messages {
+		msg := message{Role: string(m.Role)}
+		switch m.Role {
+		case provider.RoleTool:
+			msg.Parts = append(msg.Parts, part{Type: "tool_call_response", ID: m.CallID, Response: m.Text})
+		default:
+			if m.Text != "" {
+				msg.Parts = append(msg.Parts, part{Type: "text", Content: m.Text})
+			}
+			for _, c := range m.FunctionCalls {
+				msg.Parts = append(msg.Parts, part{Type: "tool_call", ID: c.CallID, Name: c.Name, Arguments: c.Arguments})
+			}
+		}
+		out = append(out, msg)
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
@@ -0,0 +1,193 @@
+package orenoagent_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// toolRoundProvider answers every question with one tool round: the first
+// model call requests the "clock" tool and the second one answers.
+type toolRoundProvider struct {
+	hooks provider.Hooks
+	tools []provider.Tool
+}
+
+func (p *toolRoundProvider) Name() string                   { return "openai:test-model" }
+func (p *toolRoundProvider) SetTools(tools []provider.Tool) { p.tools = tools }
+func (p *toolRoundProvider) SetHooks(hooks provider.Hooks)  { p.hooks = hooks }
+func (p *toolRoundProvider) ProcessMessage(ctx context.Context, yield func(provider.Result) bool, question string) error {
+	call := provider.ToolCall{CallID: "call_1", Name: "clock", Arguments: `{"zone":"UTC"}`}
+	rounds := []struct {
+		responseID string
+		reason     provider.FinishReason
+		usage      provider.Usage
+	}{
+		{"resp_1", provider.FinishReasonToolCalls, provider.Usage{InputTokens: 10, OutputTokens: 3}},
+		{"resp_2", provider.FinishReasonStop, provider.Usage{InputTokens: 20, OutputTokens: 5}},
+	}
+	for i, round := range rounds {
+		input := []provider.Message{{Role: provider.RoleUser, Text: question}}
+		err := p.hooks.CallModel(ctx, provider.ModelCall{Model: "test-model", Input: input}, func(ctx context.Context) (*provider.ModelCallCompletedResult, error) {
+			completed := provider.NewModelCallCompletedResult("test-model-2025", round.responseID, round.reason, round.usage)
+			yield(completed)
+			return completed, nil
+		})
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			yield(provider.NewFunctionCallResult(call.CallID, call.Name, call.Arguments))
+			output := p.hooks.CallTool(ctx, p.tools, call)
+			yield(provider.NewFunctionCallOutputResult(call.CallID, call.Name, output))
+		}
+	}
+	yield(provider.NewMessageResult("It is 12:00."))
+	return nil
+}
+
+func TestTracingSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	// OnResult runs in the run goroutine, so no lock is needed.
+	resultSpans := map[string]trace.SpanContext{}
+	agent := orenoagent.NewAgent(&toolRoundProvider{},
+		orenoagent.WithTracerProvider(tp),
+		orenoagent.WithTraceContent(true),
+		orenoagent.WithTools([]provider.Tool{{
+			Name:     "clock",
+			Function: func(string) string { return "12:00" },
+		}}),
+		orenoagent.WithHooks(orenoagent.Hooks{
+			OnResult: func(ctx context.Context, result orenoagent.Result) orenoagent.Result {
+				resultSpans[result.Type()] = trace.SpanFromContext(ctx).SpanContext()
+				return result
+			},
+		}),
+	)
+	subscriber, err := agent.Ask(context.Background(), "What time is it?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range subscriber.Subscribe() {
+	}
+
+	spans := exporter.GetSpans()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"chat test-model", "chat test-model", "execute_tool clock", "invoke_agent"}; !slices.Equal(names, want) {
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+
+	root := findSpan(t, spans, "invoke_agent")
+	if root.Parent.IsValid() {
+		t.Errorf("invoke_agent has parent %v, want a root span", root.Parent.SpanID())
+	}
+	for _, s := range spans {
+		if s.Name == "invoke_agent" {
+			continue
+		}
+		if s.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("%s has parent %v, want invoke_agent", s.Name, s.Parent.SpanID())
+		}
+		if s.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("%s is in another trace", s.Name)
+		}
+	}
+
+	tests := []struct {
+		span  tracetest.SpanStub
+		attrs []attribute.KeyValue
+	}{
+		{root, []attribute.KeyValue{
+			attribute.String("gen_ai.operation.name", "invoke_agent"),
+			attribute.String("gen_ai.system", "openai"),
+			attribute.StringSlice("gen_ai.response.finish_reasons", []string{"stop"}),
+		}},
+		{findSpan(t, spans, "execute_tool clock"), []attribute.KeyValue{
+			attribute.String("gen_ai.operation.name", "execute_tool"),
+			attribute.String("gen_ai.tool.name", "clock"),
+			attribute.String("gen_ai.tool.call.id", "call_1"),
+			attribute.String("gen_ai.tool.call.arguments", `{"zone":"UTC"}`),
+			attribute.String("gen_ai.tool.call.result", "12:00"),
+		}},
+	}
+	for _, chat := range spans {
+		if chat.Name != "chat test-model" {
+			continue
+		}
+		attrs := []attribute.KeyValue{
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.system", "openai"),
+			attribute.String("gen_ai.request.model", "test-model"),
+			attribute.String("gen_ai.response.model", "test-model-2025"),
+		}
+		if id := attrValue(chat, "gen_ai.response.id"); id.AsString() == "resp_1" {
+			attrs = append(attrs,
+				attribute.StringSlice("gen_ai.response.finish_reasons", []string{"tool_calls"}),
+				attribute.Int("gen_ai.usage.input_tokens", 10),
+				attribute.Int("gen_ai.usage.output_tokens", 3),
+			)
+		} else {
+			attrs = append(attrs,
+				attribute.String("gen_ai.response.id", "resp_2"),
+				attribute.StringSlice("gen_ai.response.finish_reasons", []string{"stop"}),
+				attribute.Int("gen_ai.usage.input_tokens", 20),
+				attribute.Int("gen_ai.usage.output_tokens", 5),
+			)
+		}
+		tests = append(tests, struct {
+			span  tracetest.SpanStub
+			attrs []attribute.KeyValue
+		}{chat, attrs})
+	}
+
+	for _, tt := range tests {
+		for _, want := range tt.attrs {
+			if got := attrValue(tt.span, want.Key); got != want.Value {
+				t.Errorf("%s: %s = %v, want %v", tt.span.Name, want.Key, got.Emit(), want.Value.Emit())
+			}
+		}
+	}
+	// OnResult hooks see the run span, from the first result to the last.
+	for _, typ := range []string{"run_started", "function_call", "message", "run_completed"} {
+		if sc, ok := resultSpans[typ]; !ok || sc.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("OnResult(%s): span = %v, want invoke_agent", typ, sc.SpanID())
+		}
+	}
+	if attrValue(root, "gen_ai.input.messages").AsString() == "" || attrValue(root, "gen_ai.output.messages").AsString() == "" {
+		t.Error("invoke_agent: messages not recorded with WithTraceContent")
+	}
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %s not found", name)
+	return tracetest.SpanStub{}
+}
+
+func attrValue(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
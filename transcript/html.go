@@ -0,0 +1,70 @@
+package transcript
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+// WriteHTML writes the conversation as a self-contained HTML page. Reasoning
+// and tool calls are rendered as collapsible sections.
+func WriteHTML(w io.Writer, results []orenoagent.Result) error {
+	return htmlTemplate.Execute(w, Entries(results))
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"indent": indentJSON,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Conversation</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 50rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #1f2328; }
+.entry { margin: 1rem 0; }
+.role { font-size: 0.8rem; font-weight: bold; text-transform: uppercase; color: #656d76; }
+.text { white-space: pre-wrap; }
+.user .text { background: #ddf4ff; border-radius: 0.5rem; padding: 0.5rem 0.75rem; }
+details { border: 1px solid #d0d7de; border-radius: 0.5rem; padding: 0.5rem 0.75rem; }
+summary { cursor: pointer; color: #656d76; }
+.reasoning .text { font-style: italic; color: #656d76; }
+pre { background: #f6f8fa; border-radius: 0.375rem; padding: 0.5rem; overflow-x: auto; white-space: pre-wrap; }
+.error { color: #cf222e; }
+</style>
+</head>
+<body>
+{{- range .}}
+{{- if eq .Kind "user"}}
+<div class="entry user"><div class="role">User</div><div class="text">{{.Text}}</div></div>
+{{- else if eq .Kind "reasoning"}}
+<details class="entry reasoning"><summary>Reasoning</summary><div class="text">{{.Text}}</div></details>
+{{- else if eq .Kind "message"}}
+<div class="entry message"><div class="role">Assistant</div><div class="text">{{.Text}}</div></div>
+{{- else if eq .Kind "tool_call"}}
+<details class="entry tool"><summary>Tool call: <code>{{.Name}}</code></summary>
+{{- if .Arguments}}<div>Arguments</div><pre>{{indent .Arguments}}</pre>{{end}}
+{{- if .Output}}<div>Output</div><pre>{{indent .Output}}</pre>{{end}}</details>
+{{- else if eq .Kind "error"}}
+<div class="entry error"><div class="role">Error</div><div class="text">{{.Text}}</div></div>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// indentJSON pretty-prints text if it is JSON and returns it unchanged
+// otherwise.
+func indentJSON(text string) string {
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return text
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return text
+	}
+	return string(data)
+}
@@ -0,0 +1,27 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+// WriteJSONL writes the entries of the conversation as JSON Lines, one Entry
+// per line.
+//
+// Unlike encoding the results themselves, which keeps every event of a run,
+// the output holds one line per step and is convenient for analysis with
+// tools such as jq.
+func WriteJSONL(w io.Writer, results []orenoagent.Result) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, e := range Entries(results) {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
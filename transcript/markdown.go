@@ -0,0 +1,65 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+// WriteMarkdown writes the conversation as Markdown. Reasoning is rendered
+// as a block quote and tool calls as fenced code blocks.
+func WriteMarkdown(w io.Writer, results []orenoagent.Result) error {
+	bw := bufio.NewWriter(w)
+	speaker := ""
+	for _, e := range Entries(results) {
+		role := "Assistant"
+		if e.Kind == KindUser {
+			role = "User"
+		}
+		if role != speaker || e.Kind == KindUser {
+			fmt.Fprintf(bw, "## %s\n\n", role)
+			speaker = role
+		}
+
+		switch e.Kind {
+		case KindUser, KindMessage:
+			fmt.Fprintf(bw, "%s\n\n", strings.TrimSpace(e.Text))
+		case KindReasoning:
+			fmt.Fprintf(bw, "> **Reasoning**\n>\n%s\n\n", quote(e.Text))
+		case KindToolCall:
+			fmt.Fprintf(bw, "**Tool call:** `%s`\n\n", e.Name)
+			if e.Arguments != "" {
+				writeCodeBlock(bw, "json", e.Arguments)
+			}
+			if e.Output != "" {
+				fmt.Fprint(bw, "**Output:**\n\n")
+				writeCodeBlock(bw, "", e.Output)
+			}
+		case KindError:
+			fmt.Fprintf(bw, "> **Error:** %s\n\n", strings.TrimSpace(e.Text))
+		}
+	}
+	return bw.Flush()
+}
+
+// quote prefixes every line of text with "> ".
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeCodeBlock writes text in a fenced code block whose fence is longer
+// than any run of backticks in text.
+func writeCodeBlock(w io.Writer, lang, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(w, "%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
@@ -0,0 +1,148 @@
+// Package transcript renders the results of a conversation as Markdown, a
+// self-contained HTML page or JSON Lines, to archive or share a session.
+//
+// Example usage:
+//
+//	var results []orenoagent.Result
+//	for result := range subscriber.Subscribe() {
+//		results = append(results, result)
+//	}
+//	transcript.WriteMarkdown(os.Stdout, results)
+package transcript
+
+import (
+	"github.com/demouth/orenoagent-go"
+)
+
+// Kind is the kind of an Entry.
+type Kind string
+
+const (
+	KindUser      Kind = "user"
+	KindReasoning Kind = "reasoning"
+	KindMessage   Kind = "message"
+	KindToolCall  Kind = "tool_call"
+	KindError     Kind = "error"
+)
+
+// Entry is one step of a conversation.
+type Entry struct {
+	Kind Kind `json:"kind"`
+
+	// Text is the question, the reasoning summary, the message or the error
+	// message.
+	Text string `json:"text,omitempty"`
+
+	// Tool call fields, set for KindToolCall.
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// Entries converts the results of one or more Asks into entries.
+//
+// Streamed messages and reasoning appear once: the complete result replaces
+// the delta result that preceded it. Tool outputs are attached to their call,
+// matched by call ID or, for providers without call IDs, by name in order.
+func Entries(results []orenoagent.Result) []Entry {
+	var b builder
+	for _, result := range results {
+		b.add(result)
+	}
+	return b.entries
+}
+
+type builder struct {
+	entries []Entry
+
+	// Indexes of entries built from delta results whose complete result has
+	// not arrived yet.
+	pendingMessages   []int
+	pendingReasonings []int
+
+	// Indexes of tool calls waiting for their output.
+	pendingCalls []int
+}
+
+func (b *builder) add(result orenoagent.Result) {
+	switch r := result.(type) {
+	case *orenoagent.RunStartedResult:
+		b.pendingMessages = nil
+		b.pendingReasonings = nil
+		b.pendingCalls = nil
+		b.append(Entry{Kind: KindUser, Text: r.Question()})
+	case *orenoagent.ReasoningDeltaResult:
+		if b.append(Entry{Kind: KindReasoning, Text: r.String()}) {
+			b.pendingReasonings = append(b.pendingReasonings, len(b.entries)-1)
+		}
+	case *orenoagent.ReasoningResult:
+		b.pendingReasonings = b.complete(b.pendingReasonings, Entry{Kind: KindReasoning, Text: r.String()})
+	case *orenoagent.MessageDeltaResult:
+		if b.append(Entry{Kind: KindMessage, Text: r.String()}) {
+			b.pendingMessages = append(b.pendingMessages, len(b.entries)-1)
+		}
+	case *orenoagent.MessageResult:
+		b.pendingMessages = b.complete(b.pendingMessages, Entry{Kind: KindMessage, Text: r.String()})
+	case *orenoagent.FunctionCallResult:
+		b.pendingCalls = append(b.pendingCalls, len(b.entries))
+		b.append(Entry{
+			Kind:      KindToolCall,
+			CallID:    r.CallID(),
+			Name:      r.Name(),
+			Arguments: r.Arguments(),
+		})
+	case *orenoagent.FunctionCallOutputResult:
+		b.attachOutput(r)
+	case *orenoagent.ErrorResult:
+		text := r.String()
+		if err := r.Error(); err != nil {
+			text = err.Error()
+		}
+		b.append(Entry{Kind: KindError, Text: text})
+	}
+}
+
+// append adds e unless it has no content, and reports whether it was added.
+func (b *builder) append(e Entry) bool {
+	if e.Kind != KindToolCall && e.Kind != KindUser && e.Text == "" {
+		return false
+	}
+	b.entries = append(b.entries, e)
+	return true
+}
+
+// complete replaces the oldest pending delta entry with e, or appends e if
+// nothing is pending. It returns the remaining pending indexes.
+func (b *builder) complete(pending []int, e Entry) []int {
+	if len(pending) == 0 {
+		b.append(e)
+		return nil
+	}
+	b.entries[pending[0]] = e
+	return pending[1:]
+}
+
+func (b *builder) attachOutput(r *orenoagent.FunctionCallOutputResult) {
+	match := -1
+	for j, i := range b.pendingCalls {
+		call := b.entries[i]
+		if r.CallID() != "" && call.CallID != "" {
+			if call.CallID == r.CallID() {
+				match = j
+				break
+			}
+			continue
+		}
+		if call.Name == r.Name() {
+			match = j
+			break
+		}
+	}
+	if match < 0 {
+		b.append(Entry{Kind: KindToolCall, CallID: r.CallID(), Name: r.Name(), Output: r.Output()})
+		return
+	}
+	b.entries[b.pendingCalls[match]].Output = r.Output()
+	b.pendingCalls = append(b.pendingCalls[:match], b.pendingCalls[match+1:]...)
+}
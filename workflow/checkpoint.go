@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/demouth/orenoagent-go/internal/atomicfile"
+)
+
+// Checkpointer stores the checkpoints of runs. Save replaces the previous
+// checkpoint of the run.
+type Checkpointer interface {
+	Load(ctx context.Context, runID string) (data []byte, ok bool, err error)
+	Save(ctx context.Context, runID string, data []byte) error
+}
+
+// MemoryCheckpointer keeps checkpoints in memory. It is safe for concurrent
+// use.
+type MemoryCheckpointer struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+}
+
+// NewMemoryCheckpointer creates a new MemoryCheckpointer.
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{checkpoints: map[string][]byte{}}
+}
+
+// Load implements Checkpointer.
+func (c *MemoryCheckpointer) Load(_ context.Context, runID string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.checkpoints[runID]
+	return data, ok, nil
+}
+
+// Save implements Checkpointer.
+func (c *MemoryCheckpointer) Save(_ context.Context, runID string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpoints[runID] = data
+	return nil
+}
+
+// DiskCheckpointer keeps one file per run in a directory, so that runs can
+// be resumed after a restart.
+type DiskCheckpointer struct {
+	dir string
+}
+
+// NewDiskCheckpointer creates a new DiskCheckpointer in dir. The directory
+// is created when the first checkpoint is saved.
+func NewDiskCheckpointer(dir string) *DiskCheckpointer {
+	return &DiskCheckpointer{dir: dir}
+}
+
+// Load implements Checkpointer.
+func (c *DiskCheckpointer) Load(_ context.Context, runID string) ([]byte, bool, error) {
+	data, err := os.ReadFile(c.path(runID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Save implements Checkpointer. The file is replaced atomically.
+func (c *DiskCheckpointer) Save(_ context.Context, runID string, data []byte) error {
+	return atomicfile.Write(c.path(runID), data)
+}
+
+// path returns the file of a run. The ID is hashed so that any string can be
+// used.
+func (c *DiskCheckpointer) path(runID string) string {
+	sum := sha256.Sum256([]byte(runID))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,75 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/provider"
+)
+
+// Func returns a node running a Go function that does not publish results.
+func Func[S any](fn func(ctx context.Context, state S) (Update[S], error)) Node[S] {
+	return func(ctx context.Context, state S, _ func(orenoagent.Result)) (Update[S], error) {
+		return fn(ctx, state)
+	}
+}
+
+// AgentNode returns a node that asks agent the prompt built from the state,
+// publishes the agent's results and passes its final message to apply. The
+// agent keeps its conversation between runs of the node; do not use one
+// agent in nodes that run in parallel.
+//
+// Example usage:
+//
+//	workflow.AgentNode(writer,
+//		func(s State) string { return "Write a report from these notes:\n" + s.Notes },
+//		func(s *State, answer string) { s.Draft = answer },
+//	)
+func AgentNode[S any](agent *orenoagent.Agent, prompt func(state S) string, apply func(state *S, answer string)) Node[S] {
+	return func(ctx context.Context, state S, emit func(orenoagent.Result)) (Update[S], error) {
+		subscriber, err := agent.Ask(ctx, prompt(state))
+		if err != nil {
+			return nil, err
+		}
+
+		var answer string
+		var errs []error
+		failed := false
+		for result := range subscriber.Subscribe() {
+			emit(result)
+			switch r := result.(type) {
+			case *orenoagent.MessageResult:
+				answer = r.String()
+			case *orenoagent.ErrorResult:
+				errs = append(errs, r.Error())
+			case *orenoagent.RunCompletedResult:
+				failed = r.FinishReason() == orenoagent.FinishReasonError || r.FinishReason() == orenoagent.FinishReasonCancelled
+			}
+		}
+		if failed {
+			if err := errors.Join(errs...); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("agent run failed")
+		}
+
+		return func(s *S) { apply(s, answer) }, nil
+	}
+}
+
+// ToolNode returns a node that runs tool with the arguments built from the
+// state and passes its output to apply. The call is published as a
+// FunctionCallResult and a FunctionCallOutputResult with the node name as
+// call ID.
+func ToolNode[S any](tool provider.Tool, arguments func(state S) string, apply func(state *S, output string)) Node[S] {
+	return func(ctx context.Context, state S, emit func(orenoagent.Result)) (Update[S], error) {
+		args := arguments(state)
+		call := provider.ToolCall{CallID: tool.Name, Name: tool.Name, Arguments: args}
+		emit(orenoagent.NewFunctionCallResult(call.CallID, call.Name, call.Arguments))
+		output := provider.Hooks{}.CallTool(ctx, []provider.Tool{tool}, call)
+		emit(orenoagent.NewFunctionCallOutputResult(call.CallID, call.Name, output))
+
+		return func(s *S) { apply(s, output) }, nil
+	}
+}
@@ -0,0 +1,453 @@
+// Package workflow chains agents, tools and Go functions into a graph, for
+// pipelines such as plan → parallel research → synthesize → critique.
+//
+// A run proceeds in steps. All nodes active in a step run in parallel on a
+// copy of the shared state and return updates, which are applied in the
+// order the nodes were added. The edges of the nodes that ran then decide
+// which nodes are active in the next step. The run ends when no node is
+// active.
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/demouth/orenoagent-go"
+)
+
+const (
+	// Start is the source of the edges to the first nodes.
+	Start = "__start__"
+
+	// End is the route that ends a path of the graph.
+	End = "__end__"
+)
+
+// Update changes the state. Nodes return updates instead of changing the
+// state so that nodes can run in parallel.
+type Update[S any] func(state *S)
+
+// Node is a step of a workflow. It receives a copy of the state and may
+// publish results with emit until it returns.
+type Node[S any] func(ctx context.Context, state S, emit func(orenoagent.Result)) (Update[S], error)
+
+// Graph is a workflow over a state of type S. Build it with AddNode, AddEdge,
+// AddConditionalEdge and AddJoin, then call Run. A Graph may be run several
+// times, also concurrently.
+type Graph[S any] struct {
+	nodes  map[string]Node[S]
+	order  []string
+	edges  map[string][]string
+	routes map[string][]func(S) string
+	joins  map[string][]string
+
+	maxSteps     int
+	checkpointer Checkpointer
+
+	// err is the first error made while building the graph.
+	err error
+}
+
+// Option configures a Graph.
+type Option func(*options)
+
+type options struct {
+	maxSteps     int
+	checkpointer Checkpointer
+}
+
+// WithMaxSteps limits the number of steps of a run, to stop loops such as a
+// critique loop that never converges. Zero means no limit.
+// Default: 100
+func WithMaxSteps(n int) Option {
+	return func(o *options) {
+		o.maxSteps = n
+	}
+}
+
+// WithCheckpointer saves a checkpoint after every step, so that a failed or
+// interrupted run can be continued with Resume. The state must be
+// serializable with encoding/json.
+func WithCheckpointer(checkpointer Checkpointer) Option {
+	return func(o *options) {
+		o.checkpointer = checkpointer
+	}
+}
+
+// New creates an empty Graph.
+//
+// Example usage:
+//
+//	g := workflow.New[State]()
+//	g.AddNode("plan", workflow.AgentNode(planner, planPrompt, setPlan))
+//	g.AddNode("research_a", workflow.AgentNode(researcherA, topicA, setFindingsA))
+//	g.AddNode("research_b", workflow.AgentNode(researcherB, topicB, setFindingsB))
+//	g.AddNode("synthesize", workflow.AgentNode(writer, synthesizePrompt, setDraft))
+//	g.AddEdge(workflow.Start, "plan")
+//	g.AddEdge("plan", "research_a")
+//	g.AddEdge("plan", "research_b")
+//	g.AddJoin("synthesize", "research_a", "research_b")
+//	g.AddEdge("synthesize", workflow.End)
+//
+//	run := g.Run(ctx, "run-1", State{Topic: topic})
+//	for event := range run.Subscribe() {
+//		fmt.Println(event.Node, event.Kind)
+//	}
+//	state, err := run.Wait()
+func New[S any](opts ...Option) *Graph[S] {
+	o := options{maxSteps: 100}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Graph[S]{
+		nodes:        map[string]Node[S]{},
+		edges:        map[string][]string{},
+		routes:       map[string][]func(S) string{},
+		joins:        map[string][]string{},
+		maxSteps:     o.maxSteps,
+		checkpointer: o.checkpointer,
+	}
+}
+
+// AddNode adds a node. Names must be unique.
+func (g *Graph[S]) AddNode(name string, node Node[S]) {
+	switch {
+	case name == Start || name == End:
+		g.fail(fmt.Errorf("workflow: %q is a reserved node name", name))
+	case g.nodes[name] != nil:
+		g.fail(fmt.Errorf("workflow: duplicate node %q", name))
+	default:
+		g.nodes[name] = node
+		g.order = append(g.order, name)
+	}
+}
+
+// AddEdge makes to run in the step after from. Several edges from one node
+// fan out to nodes that run in parallel.
+func (g *Graph[S]) AddEdge(from, to string) {
+	g.edges[from] = append(g.edges[from], to)
+}
+
+// AddConditionalEdge makes the node returned by route run in the step after
+// from. route receives the state after from ran, and may return End.
+func (g *Graph[S]) AddConditionalEdge(from string, route func(state S) string) {
+	g.routes[from] = append(g.routes[from], route)
+}
+
+// AddJoin makes to run once all the from nodes have run, for fan-in after
+// branches of different lengths.
+func (g *Graph[S]) AddJoin(to string, from ...string) {
+	if len(g.joins[to]) > 0 {
+		g.fail(fmt.Errorf("workflow: duplicate join into %q", to))
+		return
+	}
+	g.joins[to] = from
+	for _, f := range from {
+		g.AddEdge(f, to)
+	}
+}
+
+func (g *Graph[S]) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// validate checks that every edge leads to a known node.
+func (g *Graph[S]) validate() error {
+	if g.err != nil {
+		return g.err
+	}
+	if len(g.edges[Start]) == 0 && len(g.routes[Start]) == 0 {
+		return errors.New("workflow: no edge from Start")
+	}
+	for from, targets := range g.edges {
+		if from != Start && g.nodes[from] == nil {
+			return fmt.Errorf("workflow: edge from unknown node %q", from)
+		}
+		for _, to := range targets {
+			if to != End && g.nodes[to] == nil {
+				return fmt.Errorf("workflow: edge to unknown node %q", to)
+			}
+		}
+	}
+	for from := range g.routes {
+		if from != Start && g.nodes[from] == nil {
+			return fmt.Errorf("workflow: conditional edge from unknown node %q", from)
+		}
+	}
+	return nil
+}
+
+// Run starts a run with the initial state. runID identifies the run's
+// checkpoints; it is unused without a checkpointer.
+func (g *Graph[S]) Run(ctx context.Context, runID string, state S) *Execution[S] {
+	e := newExecution[S]()
+	go func() {
+		defer e.finish()
+		if e.err = g.validate(); e.err != nil {
+			e.state = state
+			return
+		}
+		cp := checkpoint[S]{State: state, Joins: map[string][]string{}}
+		cp.Next, e.err = g.successors([]string{Start}, state, cp.Joins)
+		if e.err == nil {
+			e.err = g.save(ctx, runID, cp)
+		}
+		if e.err != nil {
+			e.state = state
+			return
+		}
+		e.state, e.err = g.execute(ctx, runID, cp, e)
+	}()
+	return e
+}
+
+// Resume continues the run saved under runID from its last checkpoint. The
+// nodes of the step that failed or was interrupted run again.
+func (g *Graph[S]) Resume(ctx context.Context, runID string) *Execution[S] {
+	e := newExecution[S]()
+	go func() {
+		defer e.finish()
+		if e.err = g.validate(); e.err != nil {
+			return
+		}
+		if g.checkpointer == nil {
+			e.err = errors.New("workflow: resume needs a checkpointer")
+			return
+		}
+		data, ok, err := g.checkpointer.Load(ctx, runID)
+		switch {
+		case err != nil:
+			e.err = fmt.Errorf("workflow: load checkpoint: %w", err)
+			return
+		case !ok:
+			e.err = fmt.Errorf("workflow: no checkpoint for run %q", runID)
+			return
+		}
+		var cp checkpoint[S]
+		if err := json.Unmarshal(data, &cp); err != nil {
+			e.err = fmt.Errorf("workflow: decode checkpoint: %w", err)
+			return
+		}
+		if cp.Joins == nil {
+			cp.Joins = map[string][]string{}
+		}
+		e.state, e.err = g.execute(ctx, runID, cp, e)
+	}()
+	return e
+}
+
+// execute runs the steps from a checkpoint and returns the final state, or
+// the state of the last completed step on failure.
+func (g *Graph[S]) execute(ctx context.Context, runID string, cp checkpoint[S], e *Execution[S]) (S, error) {
+	for len(cp.Next) > 0 {
+		if g.maxSteps > 0 && cp.Step >= g.maxSteps {
+			return cp.State, fmt.Errorf("workflow: exceeded %d steps", g.maxSteps)
+		}
+
+		updates, err := g.step(ctx, cp, e)
+		if err != nil {
+			return cp.State, err
+		}
+
+		state := cp.State
+		for _, u := range updates {
+			if u != nil {
+				u(&state)
+			}
+		}
+		next, err := g.successors(cp.Next, state, cp.Joins)
+		if err != nil {
+			return cp.State, err
+		}
+		cp = checkpoint[S]{Step: cp.Step + 1, State: state, Next: next, Joins: cp.Joins}
+		if err := g.save(ctx, runID, cp); err != nil {
+			return cp.State, err
+		}
+	}
+	return cp.State, nil
+}
+
+// step runs the active nodes in parallel and returns their updates in node
+// order.
+func (g *Graph[S]) step(runCtx context.Context, cp checkpoint[S], e *Execution[S]) ([]Update[S], error) {
+	ctx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+
+	updates := make([]Update[S], len(cp.Next))
+	errs := make([]error, len(cp.Next))
+	var wg sync.WaitGroup
+	for i, name := range cp.Next {
+		wg.Go(func() {
+			// Events are published with the run's context, so that the
+			// events of the other nodes are not lost when one fails.
+			e.publish(runCtx, Event{Kind: EventNodeStarted, Step: cp.Step, Node: name})
+			emit := func(r orenoagent.Result) {
+				e.publish(runCtx, Event{Kind: EventResult, Step: cp.Step, Node: name, Result: r})
+			}
+			update, err := g.nodes[name](ctx, cp.State, emit)
+			if err != nil {
+				errs[i] = fmt.Errorf("workflow: node %q: %w", name, err)
+				e.publish(runCtx, Event{Kind: EventNodeFailed, Step: cp.Step, Node: name, Err: err})
+				cancel()
+				return
+			}
+			updates[i] = update
+			e.publish(runCtx, Event{Kind: EventNodeCompleted, Step: cp.Step, Node: name})
+		})
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return updates, ctx.Err()
+}
+
+// successors returns the nodes to run after the nodes that ran, in the order
+// the nodes were added. joins records which sources of each join have run.
+func (g *Graph[S]) successors(ran []string, state S, joins map[string][]string) ([]string, error) {
+	next := map[string]bool{}
+	for _, from := range ran {
+		targets := slices.Clone(g.edges[from])
+		for _, route := range g.routes[from] {
+			targets = append(targets, route(state))
+		}
+		for _, to := range targets {
+			if to == End {
+				continue
+			}
+			if g.nodes[to] == nil {
+				return nil, fmt.Errorf("workflow: route from %q to unknown node %q", from, to)
+			}
+			if sources, ok := g.joins[to]; ok && slices.Contains(sources, from) {
+				if !slices.Contains(joins[to], from) {
+					joins[to] = append(joins[to], from)
+				}
+				if len(joins[to]) < len(sources) {
+					continue
+				}
+				delete(joins, to)
+			}
+			next[to] = true
+		}
+	}
+
+	var names []string
+	for _, name := range g.order {
+		if next[name] {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (g *Graph[S]) save(ctx context.Context, runID string, cp checkpoint[S]) error {
+	if g.checkpointer == nil {
+		return nil
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("workflow: encode checkpoint: %w", err)
+	}
+	if err := g.checkpointer.Save(ctx, runID, data); err != nil {
+		return fmt.Errorf("workflow: save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// checkpoint is the progress of a run between two steps.
+type checkpoint[S any] struct {
+	Step  int                 `json:"step"`
+	State S                   `json:"state"`
+	Next  []string            `json:"next"`
+	Joins map[string][]string `json:"joins,omitempty"`
+}
+
+// EventKind is the kind of an Event.
+type EventKind string
+
+const (
+	EventNodeStarted   EventKind = "node_started"
+	EventResult        EventKind = "result"
+	EventNodeCompleted EventKind = "node_completed"
+	EventNodeFailed    EventKind = "node_failed"
+)
+
+// Event is something that happened in a run, labeled with the node and the
+// step it belongs to.
+type Event struct {
+	Kind EventKind
+	Step int
+	Node string
+
+	// Result is a result published by the node, for EventResult.
+	Result orenoagent.Result
+
+	// Err is the node's error, for EventNodeFailed.
+	Err error
+}
+
+// Execution is a running workflow.
+type Execution[S any] struct {
+	events     chan Event
+	subscribed atomic.Bool
+	done       chan struct{}
+
+	state S
+	err   error
+}
+
+func newExecution[S any]() *Execution[S] {
+	return &Execution[S]{
+		events: make(chan Event, 100),
+		done:   make(chan struct{}),
+	}
+}
+
+// publish sends event to the subscriber. When the buffer is full it waits
+// for the subscriber to catch up, unless ctx is done first.
+func (e *Execution[S]) publish(ctx context.Context, event Event) {
+	select {
+	case e.events <- event:
+		return
+	default:
+	}
+	select {
+	case e.events <- event:
+	case <-ctx.Done():
+	}
+}
+
+func (e *Execution[S]) finish() {
+	close(e.events)
+	close(e.done)
+}
+
+// Subscribe returns the events of all nodes, merged into one stream. The
+// channel is closed when the run ends. No event is dropped: the run waits
+// while the subscriber falls behind, so the channel must be read until it
+// is closed or the run's context is cancelled.
+func (e *Execution[S]) Subscribe() <-chan Event {
+	e.subscribed.Store(true)
+	return e.events
+}
+
+// Wait waits for the run to end and returns the final state. On failure it
+// returns the state after the last completed step. If Subscribe has not
+// been called, the events are discarded.
+func (e *Execution[S]) Wait() (S, error) {
+	if !e.subscribed.Load() {
+		for range e.events {
+		}
+	}
+	<-e.done
+	return e.state, e.err
+}
@@ -0,0 +1,216 @@
+package workflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demouth/orenoagent-go"
+	"github.com/demouth/orenoagent-go/workflow"
+)
+
+type state struct {
+	Log   []string
+	Count int
+}
+
+// logNode returns a node that appends its name to the log.
+func logNode(name string) workflow.Node[state] {
+	return workflow.Func(func(context.Context, state) (workflow.Update[state], error) {
+		return func(s *state) { s.Log = append(s.Log, name) }, nil
+	})
+}
+
+// steps returns the nodes that started in each step, from the events.
+func steps(events []workflow.Event) [][]string {
+	var steps [][]string
+	for _, e := range events {
+		if e.Kind != workflow.EventNodeStarted {
+			continue
+		}
+		for len(steps) <= e.Step {
+			steps = append(steps, nil)
+		}
+		steps[e.Step] = append(steps[e.Step], e.Node)
+	}
+	for _, nodes := range steps {
+		slices.Sort(nodes)
+	}
+	return steps
+}
+
+func run(t *testing.T, e *workflow.Execution[state]) ([]workflow.Event, state, error) {
+	t.Helper()
+	var events []workflow.Event
+	for event := range e.Subscribe() {
+		events = append(events, event)
+	}
+	s, err := e.Wait()
+	return events, s, err
+}
+
+func TestFanOutAndJoin(t *testing.T) {
+	// plan fans out to a short and a long branch; synthesize waits for both.
+	g := workflow.New[state]()
+	for _, name := range []string{"plan", "short", "long_1", "long_2", "synthesize"} {
+		g.AddNode(name, logNode(name))
+	}
+	g.AddEdge(workflow.Start, "plan")
+	g.AddEdge("plan", "short")
+	g.AddEdge("plan", "long_1")
+	g.AddEdge("long_1", "long_2")
+	g.AddJoin("synthesize", "short", "long_2")
+	g.AddEdge("synthesize", workflow.End)
+
+	events, s, err := run(t, g.Run(context.Background(), "run", state{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"plan"}, {"long_1", "short"}, {"long_2"}, {"synthesize"}}
+	if got := steps(events); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	// Updates of a step are applied in the order the nodes were added.
+	if got := strings.Join(s.Log, ","); got != "plan,short,long_1,long_2,synthesize" {
+		t.Errorf("log = %s", got)
+	}
+}
+
+func TestConditionalLoop(t *testing.T) {
+	g := workflow.New[state]()
+	g.AddNode("draft", workflow.Func(func(context.Context, state) (workflow.Update[state], error) {
+		return func(s *state) { s.Count++ }, nil
+	}))
+	g.AddNode("publish", logNode("publish"))
+	g.AddEdge(workflow.Start, "draft")
+	g.AddConditionalEdge("draft", func(s state) string {
+		if s.Count < 3 {
+			return "draft"
+		}
+		return "publish"
+	})
+	g.AddConditionalEdge("publish", func(state) string { return workflow.End })
+
+	events, s, err := run(t, g.Run(context.Background(), "run", state{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Count != 3 || !slices.Equal(s.Log, []string{"publish"}) {
+		t.Errorf("state = %+v, want 3 drafts and a publish", s)
+	}
+	if got := len(steps(events)); got != 4 {
+		t.Errorf("ran %d steps, want 4", got)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	g := workflow.New[state](workflow.WithMaxSteps(5))
+	g.AddNode("loop", workflow.Func(func(context.Context, state) (workflow.Update[state], error) {
+		return func(s *state) { s.Count++ }, nil
+	}))
+	g.AddEdge(workflow.Start, "loop")
+	g.AddEdge("loop", "loop")
+
+	s, err := g.Run(context.Background(), "run", state{}).Wait()
+	if err == nil || !strings.Contains(err.Error(), "exceeded 5 steps") {
+		t.Errorf("err = %v, want the step limit", err)
+	}
+	if s.Count != 5 {
+		t.Errorf("count = %d, want the state after 5 steps", s.Count)
+	}
+}
+
+func TestResume(t *testing.T) {
+	checkpointer := workflow.NewMemoryCheckpointer()
+	fail := true
+	g := workflow.New[state](workflow.WithCheckpointer(checkpointer))
+	g.AddNode("fetch", logNode("fetch"))
+	g.AddNode("flaky", workflow.Func(func(context.Context, state) (workflow.Update[state], error) {
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return func(s *state) { s.Log = append(s.Log, "flaky") }, nil
+	}))
+	g.AddNode("other", logNode("other"))
+	g.AddNode("report", logNode("report"))
+	g.AddEdge(workflow.Start, "fetch")
+	g.AddEdge("fetch", "flaky")
+	g.AddEdge("fetch", "other")
+	g.AddJoin("report", "flaky", "other")
+	g.AddEdge("report", workflow.End)
+
+	events, s, err := run(t, g.Run(context.Background(), "run-1", state{}))
+	if err == nil || !strings.Contains(err.Error(), `node "flaky": unavailable`) {
+		t.Fatalf("err = %v, want flaky's error", err)
+	}
+	if !slices.Equal(s.Log, []string{"fetch"}) {
+		t.Errorf("state after failure = %v, want the state after fetch", s.Log)
+	}
+	if !slices.ContainsFunc(events, func(e workflow.Event) bool {
+		return e.Kind == workflow.EventNodeFailed && e.Node == "flaky"
+	}) {
+		t.Error("no node_failed event for flaky")
+	}
+
+	// The failed step runs again, and fetch does not.
+	fail = false
+	events, s, err = run(t, g.Resume(context.Background(), "run-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(s.Log, ","); got != "fetch,flaky,other,report" {
+		t.Errorf("log = %s, want fetch,flaky,other,report", got)
+	}
+	want := [][]string{nil, {"flaky", "other"}, {"report"}}
+	if got := steps(events); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("resumed steps = %v, want %v", got, want)
+	}
+
+	if _, err := g.Resume(context.Background(), "unknown").Wait(); err == nil {
+		t.Error("resuming an unknown run succeeded")
+	}
+}
+
+func TestSlowSubscriberGetsEveryEvent(t *testing.T) {
+	const results = 500
+	g := workflow.New[state]()
+	g.AddNode("chatty", func(_ context.Context, _ state, emit func(orenoagent.Result)) (workflow.Update[state], error) {
+		for i := range results {
+			emit(orenoagent.NewMessageResult(fmt.Sprint(i)))
+		}
+		return nil, nil
+	})
+	g.AddEdge(workflow.Start, "chatty")
+
+	e := g.Run(context.Background(), "run", state{})
+	time.Sleep(20 * time.Millisecond) // let the buffer fill up
+	events, _, err := run(t, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(events), results+2; got != want {
+		t.Fatalf("got %d events, want %d", got, want)
+	}
+	if last := events[len(events)-1]; last.Kind != workflow.EventNodeCompleted {
+		t.Errorf("last event = %s, want node_completed", last.Kind)
+	}
+}
+
+func TestWaitWithoutSubscriber(t *testing.T) {
+	g := workflow.New[state]()
+	g.AddNode("chatty", func(_ context.Context, _ state, emit func(orenoagent.Result)) (workflow.Update[state], error) {
+		for range 500 {
+			emit(orenoagent.NewMessageResult("."))
+		}
+		return func(s *state) { s.Count = 1 }, nil
+	})
+	g.AddEdge(workflow.Start, "chatty")
+
+	if s, err := g.Run(context.Background(), "run", state{}).Wait(); err != nil || s.Count != 1 {
+		t.Errorf("Wait = %+v, %v", s, err)
+	}
+}